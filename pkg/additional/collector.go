@@ -11,6 +11,8 @@ import (
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
@@ -30,7 +32,7 @@ const (
 	diskToMirror            string = "diskToMirror"
 	mirrorToDisk            string = "mirrorToDisk"
 	errMsg                  string = "[AdditionalImagesCollector] %v "
-	logsFile                string = "logs/additional-images.log"
+	ociImageIndex           string = "application/vnd.oci.image.index.v1+json"
 )
 
 type CollectorInterface interface {
@@ -43,13 +45,14 @@ func New(log clog.PluggableLoggerInterface,
 	mirror mirror.MirrorInterface,
 	manifest manifest.ManifestInterface,
 ) CollectorInterface {
-	return &Collector{Log: log, Config: config, Opts: opts, Mirror: mirror, Manifest: manifest}
+	return &Collector{Log: log, Config: config, Opts: opts, Mirror: mirror, Manifest: manifest, Tags: &RegistryTags{Opts: &opts}}
 }
 
 type Collector struct {
 	Log      clog.PluggableLoggerInterface
 	Mirror   mirror.MirrorInterface
 	Manifest manifest.ManifestInterface
+	Tags     TagListerInterface
 	Config   v1alpha2.ImageSetConfiguration
 	Opts     mirror.CopyOptions
 }
@@ -63,11 +66,19 @@ func (o *Collector) AdditionalImagesCollector(ctx context.Context) ([]v1alpha3.C
 
 	if o.Opts.Mode == mirrorToDisk {
 		for _, img := range o.Config.ImageSetConfigurationSpec.Mirror.AdditionalImages {
-			irs, err := customImageParser(img.Name)
+			if img.Tags != nil {
+				imgs, err := o.taggedImages(ctx, img)
+				if err != nil {
+					return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+				}
+				allImages = append(allImages, imgs...)
+				continue
+			}
+			irs, err := imageref.Parse(img.Name)
 			if err != nil {
 				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
 			}
-			cacheDir := strings.Join([]string{o.Opts.Global.Dir, additionalImagesDir, irs.Namespace, irs.Component}, "/")
+			cacheDir := strings.Join([]string{o.Opts.Global.Dir, additionalImagesDir, imageref.Dir(irs)}, "/")
 			if _, err := os.Stat(cacheDir); errors.Is(err, os.ErrNotExist) {
 				err := os.MkdirAll(cacheDir, 0755)
 				if err != nil {
@@ -78,7 +89,7 @@ func (o *Collector) AdditionalImagesCollector(ctx context.Context) ([]v1alpha3.C
 				dest := transport + cacheDir
 				o.Log.Debug("source %s", src)
 				o.Log.Debug("destination %s", dest)
-				allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest})
+				allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest, Architectures: img.Architectures})
 			} else {
 				o.Log.Info("cache dir exists %s", cacheDir)
 			}
@@ -86,45 +97,153 @@ func (o *Collector) AdditionalImagesCollector(ctx context.Context) ([]v1alpha3.C
 	}
 
 	if o.Opts.Mode == diskToMirror {
-		regex, e := regexp.Compile(indexJson)
-		if e != nil {
-			o.Log.Error("%v", e)
+		regex, err := regexp.Compile(indexJson)
+		if err != nil {
+			return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+		}
+		additionalImages := o.Config.Mirror.AdditionalImages
+		// discover the additional images dirs when none are set
+		if len(additionalImages) == 0 && len(o.Opts.Global.AdditionalFrom) > 0 {
+			dirs, err := DiskImageDirs(o.Opts.Global.AdditionalFrom)
+			if err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+			o.Log.Info("additional images found on disk %v", dirs)
+			for _, dir := range dirs {
+				additionalImages = append(additionalImages, v1alpha2.Image{Name: dirProtocol + dir})
+			}
 		}
-		for _, addImg := range o.Config.Mirror.AdditionalImages {
+		for _, addImg := range additionalImages {
 			imagesDir := strings.Replace(addImg.Name, "dir://", "", 1)
-			e = filepath.Walk(imagesDir, func(path string, info os.FileInfo, err error) error {
-				if err == nil && regex.MatchString(info.Name()) {
-					hld := strings.Split(filepath.Dir(path), additionalImagesDir)
-					//ref := filepath.Dir(strings.Join(hld, "/"))
-					src := ociProtocolTrimmed + filepath.Dir(path)
-					dest := o.Opts.Destination + hld[1]
-					allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest})
+			err := filepath.Walk(imagesDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !regex.MatchString(info.Name()) {
+					return nil
 				}
+				hld := strings.Split(filepath.Dir(path), additionalImagesDir)
+				if len(hld) < 2 {
+					return fmt.Errorf("%s is not in an %s dir", path, additionalImagesDir)
+				}
+				src := ociProtocolTrimmed + filepath.Dir(path)
+				// the cache dir is <path>/<tag> or <path>/sha256-<hash>
+				dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeAdditional, imageref.FromDir(hld[1]))
+				copyImage := v1alpha3.CopyImageSchema{Source: src, Destination: dest, PreserveDigests: strings.Contains(dest, "@")}
+				// the (trimmed) index of the selected architectures is pushed as is
+				if o.layoutManifest(filepath.Dir(path)).MediaType == ociImageIndex {
+					copyImage.MultiArch = "all"
+				}
+				allImages = append(allImages, copyImage)
 				return nil
 			})
-		}
-		if e != nil {
-			return []v1alpha3.CopyImageSchema{}, e
+			if err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+			// the images written to the blob store (mirrorToDisk --blob-store)
+			imgs, err := o.storeImages(imagesDir)
+			if err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+			allImages = append(allImages, imgs...)
 		}
 	}
 	return allImages, nil
 }
 
-// customImageParser - simple image string parser
-func customImageParser(image string) (*v1alpha3.ImageRefSchema, error) {
-	var irs *v1alpha3.ImageRefSchema
-	var component string
-	parts := strings.Split(image, "/")
-	if len(parts) < 3 {
-		return irs, fmt.Errorf("[customImageParser] image url seems to be wrong %s ", image)
+// storeImages - returns the images of the additional images dir in the blob store of its working dir
+// the ref names are the cache paths additional-images/<path>/<tag> or <path>/sha256-<hash>
+func (o *Collector) storeImages(imagesDir string) ([]v1alpha3.CopyImageSchema, error) {
+	workingDir, prefix, ok := blobstore.Split(imagesDir, additionalImagesDir)
+	if !ok {
+		return nil, nil
 	}
-	component = parts[2]
-	if strings.Contains(parts[2], "@") {
-		component = strings.Split(parts[2], "@")[0]
+	images, err := blobstore.Images(workingDir, prefix)
+	if err != nil {
+		return nil, err
 	}
-	if strings.Contains(parts[2], ":") {
-		component = strings.Split(parts[2], ":")[0]
+	var result []v1alpha3.CopyImageSchema
+	for _, img := range images {
+		o.Log.Debug("blob store image %s", img.Ref)
+		dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeAdditional, imageref.FromDir(strings.TrimPrefix(img.Ref, additionalImagesDir)))
+		copyImage := v1alpha3.CopyImageSchema{Source: img.Source, Destination: dest, PreserveDigests: strings.Contains(dest, "@")}
+		if img.IsList() {
+			copyImage.MultiArch = "all"
+		}
+		result = append(result, copyImage)
+	}
+	return result, nil
+}
+
+// DiskImageDirs - returns the additional-images directories under dir
+func DiskImageDirs(dir string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == blobstore.StoreDir {
+			return filepath.SkipDir
+		}
+		if d.IsDir() && d.Name() == additionalImagesDir {
+			dirs = append(dirs, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// taggedImages - expands a repository into the selected tags
+// each tag is copied to its own cache dir (<path>/<tag>) with the transport of the destination
+// (as for the untagged images), an oci layout gets the tag as the ref.name
+func (o *Collector) taggedImages(ctx context.Context, img v1alpha2.Image) ([]v1alpha3.CopyImageSchema, error) {
+	var result []v1alpha3.CopyImageSchema
+	repository := strings.TrimPrefix(img.Name, dockerProtocol)
+	irs, err := imageref.Parse(repository)
+	if err != nil {
+		return result, err
+	}
+	tags, err := o.Tags.ListTags(ctx, repository)
+	if err != nil {
+		return result, err
+	}
+	selected, err := selectTags(tags, *img.Tags)
+	if err != nil {
+		return result, err
+	}
+	o.Log.Info("selected tags for %s %v", repository, selected)
+	for _, tag := range selected {
+		irs.Tag = tag
+		cacheDir := strings.Join([]string{o.Opts.Global.Dir, additionalImagesDir, imageref.Dir(irs)}, "/")
+		if _, err := os.Stat(cacheDir); err == nil {
+			o.Log.Info("cache dir exists %s", cacheDir)
+			continue
+		}
+		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+			return result, err
+		}
+		src := dockerProtocol + repository + ":" + tag
+		transport := strings.Split(o.Opts.Destination, "://")[0] + ":"
+		dest := transport + cacheDir
+		if transport == ociProtocolTrimmed {
+			dest += ":" + tag
+		}
+		o.Log.Debug("source %s", src)
+		o.Log.Debug("destination %s", dest)
+		result = append(result, v1alpha3.CopyImageSchema{Source: src, Destination: dest, Architectures: img.Architectures})
+	}
+	return result, nil
+}
+
+// layoutManifest - returns the top level manifest (descriptor) of an oci layout
+func (o *Collector) layoutManifest(dir string) v1alpha3.OCIManifest {
+	oci, err := o.Manifest.GetImageIndex(dir)
+	if err != nil || len(oci.Manifests) != 1 {
+		return v1alpha3.OCIManifest{}
 	}
-	irs = &v1alpha3.ImageRefSchema{Repository: parts[0], Namespace: parts[1], Component: component}
-	return irs, nil
+	return oci.Manifests[0]
 }
@@ -3,6 +3,8 @@ package additional
 import (
 	"bufio"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
@@ -154,6 +156,74 @@ func TestAdditionalImageCollector(t *testing.T) {
 	// TODO: cover negative cases
 }
 
+func TestAdditionalImageCollectorDiskToMirror(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	layout := filepath.Join(dir, "mirror", additionalImagesDir, "registry.redhat.io/ubi8/ubi/latest")
+	if err := os.MkdirAll(layout, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(layout, indexJson), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := mirror.CopyOptions{
+		Global:      &mirror.GlobalOptions{Dir: dir, AdditionalFrom: dir},
+		Destination: "docker://localhost:5000/test",
+		Mode:        diskToMirror,
+	}
+
+	t.Run("Testing AdditionalImagesCollector diskToMirror discover : should pass", func(t *testing.T) {
+		ex := &Collector{Log: log, Opts: opts, Manifest: &Manifest{Log: log}}
+		res, err := ex.AdditionalImagesCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 1 || res[0].Source != "oci:"+layout || res[0].Destination != "docker://localhost:5000/test/registry.redhat.io/ubi8/ubi:latest" {
+			t.Fatalf("should return the additional image got %v", res)
+		}
+	})
+
+	t.Run("Testing AdditionalImagesCollector diskToMirror missing dir : should fail", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.AdditionalImages = []v1alpha2.Image{
+			{Name: "dir://" + filepath.Join(dir, "mirror", additionalImagesDir, "missing")},
+			{Name: "dir://" + filepath.Join(dir, "mirror", additionalImagesDir)},
+		}
+		ex := &Collector{Log: log, Config: cfg, Opts: opts, Manifest: &Manifest{Log: log}}
+		if res, err := ex.AdditionalImagesCollector(context.Background()); err == nil {
+			t.Fatalf("should fail on the first walk error got %v", res)
+		}
+	})
+
+	t.Run("Testing AdditionalImagesCollector diskToMirror blob store : should pass", func(t *testing.T) {
+		// a store per image type, the ref name is the cache path of the image
+		hash := "e2c2bd0ef1f4e2d3c48c3e0e1c8c9bd8ee5b7b7eb4c5b4e5b4c3f2e1d0c9b8a7"
+		store := filepath.Join(dir, "mirror", "blob-store", additionalImagesDir)
+		if err := os.MkdirAll(store, 0755); err != nil {
+			t.Fatal(err)
+		}
+		index := `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:` + hash + `","size":1,` +
+			`"annotations":{"org.opencontainers.image.ref.name":"additional-images/registry.redhat.io/ubi9/ubi/sha256-` + hash + `"}}]}`
+		if err := os.WriteFile(filepath.Join(store, indexJson), []byte(index), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(filepath.Join(dir, "mirror", "blob-store"))
+		ex := &Collector{Log: log, Opts: opts, Manifest: &Manifest{Log: log}}
+		res, err := ex.AdditionalImagesCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("should return the cached and the stored image got %v", res)
+		}
+		img := res[1]
+		if img.Source != "oci:"+store+":additional-images/registry.redhat.io/ubi9/ubi/sha256-"+hash ||
+			img.Destination != "docker://localhost:5000/test/registry.redhat.io/ubi9/ubi@sha256:"+hash || !img.PreserveDigests || img.MultiArch != "all" {
+			t.Fatalf("should return the blob store image got %v", img)
+		}
+	})
+}
+
 // setup mocks
 // we need to mock Manifest, Mirror
 
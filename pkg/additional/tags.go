@@ -0,0 +1,89 @@
+package additional
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/containers/image/v5/docker"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+)
+
+type TagListerInterface interface {
+	ListTags(ctx context.Context, repository string) ([]string, error)
+}
+
+// RegistryTags - lists the tags of a repository using the registry api (containers/image)
+type RegistryTags struct {
+	Opts *mirror.CopyOptions
+}
+
+// ListTags - returns all the tags of the repository (registry/namespace/name)
+func (o *RegistryTags) ListTags(ctx context.Context, repository string) ([]string, error) {
+	ref, err := docker.ParseReference("//" + repository)
+	if err != nil {
+		return nil, err
+	}
+	sysCtx, err := o.Opts.SrcImage.NewSystemContext()
+	if err != nil {
+		return nil, err
+	}
+	return docker.GetRepositoryTags(ctx, sysCtx, ref)
+}
+
+// selectTags - filters the tags (regex, semver range) and keeps the latest N
+// tags that are not semver are dropped when a semver range or latest is set
+func selectTags(tags []string, sel v1alpha2.TagSelection) ([]string, error) {
+	var re *regexp.Regexp
+	if len(sel.Regex) > 0 {
+		var err error
+		if re, err = regexp.Compile(sel.Regex); err != nil {
+			return nil, fmt.Errorf("tags regex %q: %v", sel.Regex, err)
+		}
+	}
+	var inRange semver.Range
+	if len(sel.SemverRange) > 0 {
+		var err error
+		if inRange, err = semver.ParseRange(sel.SemverRange); err != nil {
+			return nil, fmt.Errorf("tags semverRange %q: %v", sel.SemverRange, err)
+		}
+	}
+	useSemver := inRange != nil || sel.Latest > 0
+
+	type tagVersion struct {
+		tag     string
+		version semver.Version
+	}
+	var selected []tagVersion
+	for _, tag := range tags {
+		if re != nil && !re.MatchString(tag) {
+			continue
+		}
+		tv := tagVersion{tag: tag}
+		if useSemver {
+			v, err := semver.ParseTolerant(tag)
+			if err != nil || (inRange != nil && !inRange(v)) {
+				continue
+			}
+			tv.version = v
+		}
+		selected = append(selected, tv)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		if useSemver {
+			return selected[i].version.LT(selected[j].version)
+		}
+		return selected[i].tag < selected[j].tag
+	})
+	if sel.Latest > 0 && len(selected) > sel.Latest {
+		selected = selected[len(selected)-sel.Latest:]
+	}
+	result := make([]string, 0, len(selected))
+	for _, tv := range selected {
+		result = append(result, tv.tag)
+	}
+	return result, nil
+}
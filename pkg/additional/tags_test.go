@@ -0,0 +1,104 @@
+package additional
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectTags(t *testing.T) {
+	tags := []string{"latest", "v1.2.0", "v1.4.0", "v1.4.1", "v1.10.0", "v2.0.0", "1.5.0", "v1.6.0-rc1"}
+
+	type spec struct {
+		name     string
+		sel      v1alpha2.TagSelection
+		expected []string
+		expError bool
+	}
+	cases := []spec{
+		{name: "regex", sel: v1alpha2.TagSelection{Regex: `^v1\.`}, expected: []string{"v1.10.0", "v1.2.0", "v1.4.0", "v1.4.1", "v1.6.0-rc1"}},
+		{name: "semverRange", sel: v1alpha2.TagSelection{SemverRange: ">=1.4.0 <2.0.0"}, expected: []string{"v1.4.0", "v1.4.1", "1.5.0", "v1.6.0-rc1", "v1.10.0"}},
+		{name: "latest", sel: v1alpha2.TagSelection{Latest: 2}, expected: []string{"v1.10.0", "v2.0.0"}},
+		{name: "all", sel: v1alpha2.TagSelection{Regex: `^v`, SemverRange: ">=1.4.0 <2.0.0", Latest: 3}, expected: []string{"v1.4.1", "v1.6.0-rc1", "v1.10.0"}},
+		{name: "invalid regex", sel: v1alpha2.TagSelection{Regex: `^v(`}, expError: true},
+		{name: "invalid range", sel: v1alpha2.TagSelection{SemverRange: ">=x"}, expError: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := selectTags(tags, c.sel)
+			if c.expError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, res)
+		})
+	}
+}
+
+func TestAdditionalImagesTags(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	cfg := v1alpha2.ImageSetConfiguration{}
+	cfg.Mirror.AdditionalImages = []v1alpha2.Image{
+		{Name: "registry.local/team/app", Tags: &v1alpha2.TagSelection{SemverRange: ">=1.4.0", Latest: 2}, Architectures: []string{"amd64", "arm64"}},
+	}
+
+	t.Run("Testing AdditionalImagesCollector mirrorToDisk (tags) : should pass", func(t *testing.T) {
+		opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: dir}, Mode: mirrorToDisk, Destination: "oci://test"}
+		ex := &Collector{Log: log, Config: cfg, Opts: opts, Manifest: &Manifest{}, Tags: &Tags{Tags: []string{"v1.3.0", "v1.4.0", "v1.5.0", "v1.6.0"}}}
+		res, err := ex.AdditionalImagesCollector(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []v1alpha3.CopyImageSchema{
+			{Source: "docker://registry.local/team/app:v1.5.0", Destination: "oci:" + dir + "/additional-images/team/app/v1.5.0:v1.5.0", Architectures: []string{"amd64", "arm64"}},
+			{Source: "docker://registry.local/team/app:v1.6.0", Destination: "oci:" + dir + "/additional-images/team/app/v1.6.0:v1.6.0", Architectures: []string{"amd64", "arm64"}},
+		}, res)
+
+		// the cached tags are skipped
+		res, err = ex.AdditionalImagesCollector(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+
+	t.Run("Testing AdditionalImagesCollector mirrorToDisk (tags, dir destination) : should pass", func(t *testing.T) {
+		dirDest := t.TempDir()
+		opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: dirDest}, Mode: mirrorToDisk, Destination: "dir://test"}
+		ex := &Collector{Log: log, Config: cfg, Opts: opts, Manifest: &Manifest{}, Tags: &Tags{Tags: []string{"v1.5.0", "v1.6.0"}}}
+		res, err := ex.AdditionalImagesCollector(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []v1alpha3.CopyImageSchema{
+			{Source: "docker://registry.local/team/app:v1.5.0", Destination: "dir:" + dirDest + "/additional-images/team/app/v1.5.0", Architectures: []string{"amd64", "arm64"}},
+			{Source: "docker://registry.local/team/app:v1.6.0", Destination: "dir:" + dirDest + "/additional-images/team/app/v1.6.0", Architectures: []string{"amd64", "arm64"}},
+		}, res)
+	})
+
+	t.Run("Testing AdditionalImagesCollector diskToMirror (tags) : should pass", func(t *testing.T) {
+		index := `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:abc","size":1,"annotations":{"org.opencontainers.image.ref.name":"v1.5.0"}}]}`
+		require.NoError(t, os.WriteFile(dir+"/additional-images/team/app/v1.5.0/index.json", []byte(index), 0644))
+		d2mCfg := v1alpha2.ImageSetConfiguration{}
+		d2mCfg.Mirror.AdditionalImages = []v1alpha2.Image{{Name: "dir://" + dir + "/additional-images"}}
+		opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: dir}, Mode: diskToMirror, Destination: "docker://localhost:5000/test"}
+		ex := &Collector{Log: log, Config: d2mCfg, Opts: opts, Manifest: manifest.New(log)}
+		res, err := ex.AdditionalImagesCollector(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []v1alpha3.CopyImageSchema{
+			{Source: "oci:" + dir + "/additional-images/team/app/v1.5.0", Destination: "docker://localhost:5000/test/team/app:v1.5.0", MultiArch: "all"},
+		}, res)
+	})
+}
+
+// Tags - mocks the registry tags list
+type Tags struct {
+	Tags []string
+}
+
+func (o *Tags) ListTags(ctx context.Context, repository string) ([]string, error) {
+	return o.Tags, nil
+}
@@ -43,11 +43,42 @@ type Mirror struct {
 	// from the mirroring process if they exist in other content
 	// types in the configuration.
 	BlockedImages []Image `json:"blockedImages,omitempty"`
+	// AllowBlockedReleaseImages allows blocked images to be removed
+	// from the release payload (the release will be incomplete).
+	AllowBlockedReleaseImages bool `json:"allowBlockedReleaseImages,omitempty"`
+	// DestinationRules remap the repository paths at the destination (diskToMirror).
+	DestinationRules DestinationRules `json:"destinationRules,omitempty"`
 	// Samples defines the configuration for Sample content types.
 	// This is currently not implemented.
 	Samples []SampleImages `json:"samples,omitempty"`
 }
 
+// DestinationRules define how the repository paths are mapped at the destination.
+// The rules are applied in order: prefix rewrite, flattening, then the root of the image type.
+type DestinationRules struct {
+	// PrefixRewrites replace a repository path prefix (the first match is used).
+	PrefixRewrites []PrefixRewrite `json:"prefixRewrites,omitempty"`
+	// FlattenDepth limits the repository path to N levels, the leading
+	// namespaces are joined with "-" (i.e a/b/c/app with 2 is a-b-c/app).
+	FlattenDepth int `json:"flattenDepth,omitempty"`
+	// Roots are the root paths per image type.
+	Roots DestinationRoots `json:"roots,omitempty"`
+}
+
+// PrefixRewrite replaces the From repository path prefix with To.
+type PrefixRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DestinationRoots define the root path (below the destination) per image type.
+type DestinationRoots struct {
+	Release          string `json:"release,omitempty"`
+	Operators        string `json:"operators,omitempty"`
+	AdditionalImages string `json:"additionalImages,omitempty"`
+	Helm             string `json:"helm,omitempty"`
+}
+
 // Platform defines the configuration for OpenShift and OKD platform types.
 type Platform struct {
 	// Graph defines whether Cincinnati graph data will
@@ -63,6 +94,52 @@ type Platform struct {
 	// This new field will allow the diskToMirror functionality
 	// to copy from a release location on disk
 	Release string `json:"release,omitempty"`
+	// ReleaseDirs allows the diskToMirror functionality to copy
+	// from a list of release locations on disk. If neither Release
+	// or ReleaseDirs is set all releases in the working dir are copied
+	ReleaseDirs []string `json:"releaseDirs,omitempty"`
+	// Releases defines a list of exact release versions
+	// or explicit release payload references to mirror
+	Releases []Release `json:"releases,omitempty"`
+	// Components filters the release components (image-references
+	// tag names) to mirror. By default all components are mirrored
+	Components ComponentFilter `json:"components,omitempty"`
+	// BootImages defines whether the coreos boot image
+	// artifact URLs are listed for each release
+	BootImages BootImages `json:"bootImages,omitempty"`
+}
+
+// BootImages defines the listing of the coreos boot image
+// artifacts (the stream metadata is always saved)
+type BootImages struct {
+	// ListArtifacts writes the artifact URLs for each release
+	ListArtifacts bool `json:"listArtifacts,omitempty"`
+	// Formats limits the listing to the matching formats
+	// (i.e. iso, pxe, qcow2.gz), by default all formats are listed
+	Formats []string `json:"formats,omitempty"`
+}
+
+// ComponentFilter defines glob patterns (i.e. "*alibaba*") matched
+// against the release component names
+type ComponentFilter struct {
+	// Include only the components that match one of the patterns
+	Include []string `json:"include,omitempty"`
+	// Exclude the components that match one of the patterns
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Release defines an exact release to mirror, either a version
+// that is resolved through Cincinnati or a digest pinned
+// release payload image (i.e. a CI or hotfix build)
+type Release struct {
+	// Version is the exact release version to mirror
+	Version string `json:"version,omitempty"`
+	// Channel is used to resolve the version, if unset
+	// the stable channel for the version is used
+	Channel string `json:"channel,omitempty"`
+	// Image is a digest pinned release payload pull spec
+	// (registry/namespace/name@sha256:<hash>)
+	Image string `json:"image,omitempty"`
 }
 
 // ReleaseChannel defines the configuration for individual
@@ -81,6 +158,12 @@ type ReleaseChannel struct {
 	// ShortestPath mode calculates the shortest path
 	// between the min and mav version
 	ShortestPath bool `json:"shortestPath,omitempty"`
+	// EUS mode calculates the minimal set of releases
+	// for an EUS-to-EUS upgrade between the min and max
+	// version (both even minor versions), including the
+	// intermediate odd minor release. The channel name
+	// should be the target EUS channel i.e. eus-4.14
+	EUS bool `json:"eus,omitempty"`
 	// Full mode set the MinVersion to the
 	// first release in the channel and the MaxVersion
 	// to the last release in the channel.
@@ -171,6 +254,9 @@ type Helm struct {
 	Repositories []Repository `json:"repositories,omitempty"`
 	// Local is the configuration for locally stored helm charts
 	Local []Chart `json:"local,omitempty"`
+	// PublishCharts pushes the charts as OCI artifacts
+	// to the destination registry in diskToMirror
+	PublishCharts bool `json:"publishCharts,omitempty"`
 }
 
 // Repository defines the configuration for a Helm repository.
@@ -205,6 +291,22 @@ type Image struct {
 	// Name of the image. This should be an exact image pin (registry/namespace/name@sha256:<hash>)
 	// but is not required to be.
 	Name string `json:"name"`
+	// Tags selects the tags to mirror when the name is a repository
+	// (without a tag or digest).
+	Tags *TagSelection `json:"tags,omitempty"`
+	// Architectures of a multi-arch image to mirror i.e [amd64, arm64]
+	// (only the system architecture is mirrored when not set).
+	Architectures []string `json:"architectures,omitempty"`
+}
+
+// TagSelection selects the tags of a repository. All the set conditions must match.
+type TagSelection struct {
+	// Regex the tags must match
+	Regex string `json:"regex,omitempty"`
+	// SemverRange the tags must satisfy i.e ">=1.4.0 <2.0.0"
+	SemverRange string `json:"semverRange,omitempty"`
+	// Latest limits the selection to the N highest (semver) tags
+	Latest int `json:"latest,omitempty"`
 }
 
 // SampleImages define the configuration
@@ -94,9 +94,19 @@ type OCISchema struct {
 }
 
 type OCIManifest struct {
-	MediaType string `json:"mediaType"`
-	Digest    string `json:"digest"`
-	Size      int    `json:"size"`
+	MediaType string       `json:"mediaType"`
+	Digest    string       `json:"digest"`
+	Size      int          `json:"size"`
+	Platform  *OCIPlatform `json:"platform,omitempty"`
+	// Annotations i.e org.opencontainers.image.ref.name (the tag in an oci layout)
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIPlatform - the platform of a manifest in an image index (manifest list)
+type OCIPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
 }
 
 // OperatorConfigSchema
@@ -233,6 +243,8 @@ type Bundle struct {
 type RelatedImage struct {
 	Name  string `json:"name"`
 	Image string `json:"image"`
+	// Annotations - the build annotations of a release component (image-references)
+	Annotations *Annotations `json:"annotations,omitempty"`
 }
 
 // DeclarativeConfig this updates the existing dclrcfg
@@ -258,15 +270,30 @@ type ISCPackage struct {
 
 // ImageRefSchema used to return parsed Image data
 type ImageRefSchema struct {
+	// Repository is the registry (host and port)
 	Repository string
-	Namespace  string
-	Component  string
+	// Namespace is the path without the component (can be nested i.e org/team)
+	Namespace string
+	Component string
+	// Path is the full repository path (Namespace/Component)
+	Path   string
+	Tag    string
+	Digest string
 }
 
 // CopyImageSchema
 type CopyImageSchema struct {
 	Source      string
 	Destination string
+	// MultiArch overrides the global multi-arch option for this image
+	// (one of system, all, index-only)
+	MultiArch string
+	// PreserveDigests fails the copy if the digest of the image would change
+	PreserveDigests bool
+	// Architectures copies only these instances of a manifest list (the index is trimmed)
+	Architectures []string
+	// Origin is the collector that wanted the image (release, operator, additional, helm)
+	Origin string
 }
 
 // SignatureContentSchema
@@ -284,3 +311,27 @@ type SignatureContentSchema struct {
 		Creator string `json:"creator"`
 	} `json:"optional"`
 }
+
+// CoreOSStream - the (minimal) coreos stream metadata
+// found in the coreos-bootimages ConfigMap of a release
+type CoreOSStream struct {
+	Stream        string                `json:"stream"`
+	Architectures map[string]CoreOSArch `json:"architectures"`
+}
+
+// CoreOSArch - the boot artifacts for an architecture
+type CoreOSArch struct {
+	Artifacts map[string]CoreOSPlatform `json:"artifacts"`
+}
+
+// CoreOSPlatform - the boot artifacts for a platform (metal, qemu, ...)
+type CoreOSPlatform struct {
+	Release string                               `json:"release"`
+	Formats map[string]map[string]CoreOSArtifact `json:"formats"`
+}
+
+// CoreOSArtifact - a single downloadable boot artifact
+type CoreOSArtifact struct {
+	Location string `json:"location"`
+	Sha256   string `json:"sha256"`
+}
@@ -0,0 +1,58 @@
+package batch
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+)
+
+const (
+	dockerProtocol string = "docker://"
+)
+
+// SkipPresentImages - removes the images whose manifest digest is already at the destination
+// the local and destination digests are compared (HEAD request), at most BATCH_SIZE at a time
+// an image is kept (copied) if either digest can't be resolved i.e not found at the destination
+func SkipPresentImages(ctx context.Context, log clog.PluggableLoggerInterface, digests mirror.DigestInterface, images []v1alpha3.CopyImageSchema, opts mirror.CopyOptions) ([]v1alpha3.CopyImageSchema, int) {
+	var wg sync.WaitGroup
+	present := make([]bool, len(images))
+	sem := make(chan struct{}, BATCH_SIZE)
+
+	for i, img := range images {
+		if !strings.HasPrefix(img.Destination, dockerProtocol) || strings.HasPrefix(img.Source, dockerProtocol) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, img v1alpha3.CopyImageSchema) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			local, err := digests.LocalDigest(ctx, img.Source, &opts)
+			if err != nil {
+				log.Debug("local digest %s : %v", img.Source, err)
+				return
+			}
+			remote, err := digests.RemoteDigest(ctx, img.Destination, &opts)
+			if err != nil {
+				log.Debug("destination digest %s : %v", img.Destination, err)
+				return
+			}
+			present[i] = local == remote
+		}(i, img)
+	}
+	wg.Wait()
+
+	var result []v1alpha3.CopyImageSchema
+	for i, img := range images {
+		if present[i] {
+			log.Debug("image already at destination %s ", img.Destination)
+			continue
+		}
+		result = append(result, img)
+	}
+	return result, len(images) - len(result)
+}
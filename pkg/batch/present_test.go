@@ -0,0 +1,76 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestSkipPresentImages(t *testing.T) {
+	log := clog.New("trace")
+
+	t.Run("Testing SkipPresentImages : should pass", func(t *testing.T) {
+		digests := &Digests{
+			local: map[string]digest.Digest{
+				"oci:/cache/a": "sha256:aaa",
+				"oci:/cache/b": "sha256:bbb",
+				"oci:/cache/c": "sha256:ccc",
+				"dir:/cache/d": "sha256:ddd",
+			},
+			remote: map[string]digest.Digest{
+				"docker://localhost:5000/a:v1": "sha256:aaa",
+				"docker://localhost:5000/b:v1": "sha256:old",
+				"docker://localhost:5000/d:v1": "sha256:ddd",
+			},
+		}
+		images := []v1alpha3.CopyImageSchema{
+			{Source: "oci:/cache/a", Destination: "docker://localhost:5000/a:v1"},
+			{Source: "oci:/cache/b", Destination: "docker://localhost:5000/b:v1"},
+			{Source: "oci:/cache/c", Destination: "docker://localhost:5000/c:v1"},
+			{Source: "dir:/cache/d", Destination: "docker://localhost:5000/d:v1"},
+			{Source: "docker://registry/e:v1", Destination: "oci:/cache/e"},
+		}
+		res, skipped := SkipPresentImages(context.Background(), log, digests, images, mirror.CopyOptions{})
+		if skipped != 2 {
+			t.Fatalf("should skip 2 images got %d", skipped)
+		}
+		expected := []v1alpha3.CopyImageSchema{images[1], images[2], images[4]}
+		if fmt.Sprintf("%v", res) != fmt.Sprintf("%v", expected) {
+			t.Fatalf("should keep the order of the images to copy %v", res)
+		}
+		if digests.remoteCalls != 4 {
+			t.Fatalf("should only check docker destinations got %d", digests.remoteCalls)
+		}
+	})
+}
+
+// Digests - mocks the digest lookups
+type Digests struct {
+	mu          sync.Mutex
+	local       map[string]digest.Digest
+	remote      map[string]digest.Digest
+	remoteCalls int
+}
+
+func (o *Digests) LocalDigest(ctx context.Context, image string, opts *mirror.CopyOptions) (digest.Digest, error) {
+	if d, ok := o.local[image]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("no manifest %s", image)
+}
+
+func (o *Digests) RemoteDigest(ctx context.Context, image string, opts *mirror.CopyOptions) (digest.Digest, error) {
+	o.mu.Lock()
+	o.remoteCalls++
+	o.mu.Unlock()
+	if d, ok := o.remote[image]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("manifest unknown %s", image)
+}
@@ -1,28 +1,28 @@
 package batch
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/report"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/runlog"
 )
 
 const (
-	BATCH_SIZE int    = 8
-	logFile    string = "logs/worker-{batch}.log"
+	BATCH_SIZE int = 8
 )
 
 type BatchInterface interface {
 	Worker(ctx context.Context, images []v1alpha3.CopyImageSchema, opts mirror.CopyOptions) error
+	Results() []report.Image
 }
 
 func New(log clog.PluggableLoggerInterface,
@@ -36,6 +36,8 @@ type Batch struct {
 	Log      clog.PluggableLoggerInterface
 	Mirror   mirror.MirrorInterface
 	Manifest manifest.ManifestInterface
+	results  []report.Image
+	mu       sync.Mutex
 }
 
 type BatchSchema struct {
@@ -53,7 +55,6 @@ func (o *Batch) Worker(ctx context.Context, images []v1alpha3.CopyImageSchema, o
 
 	var errArray []error
 	var wg sync.WaitGroup
-	var err error
 
 	var b *BatchSchema
 	imgs := len(images)
@@ -69,35 +70,35 @@ func (o *Batch) Worker(ctx context.Context, images []v1alpha3.CopyImageSchema, o
 	o.Log.Info("batch size %d ", b.BatchSize)
 	o.Log.Info("remainder size %d ", b.Remainder)
 
-	f := make([]*os.File, b.Count)
-	//f, err := make([]os.File)
 	// prepare batching
 	wg.Add(b.BatchSize)
 	for i := 0; i < b.Count; i++ {
-		// create a log file for each batch
-		f[i], err = os.Create(strings.Replace(logFile, "{batch}", strconv.Itoa(i), -1))
-		if err != nil {
-			o.Log.Error("[Worker] %v", err)
-		}
-		writer := bufio.NewWriter(f[i])
 		o.Log.Info(fmt.Sprintf("starting batch %d ", i))
 		for x := 0; x < b.BatchSize; x++ {
 			index := (i * b.BatchSize) + x
-			o.Log.Debug("source %s ", images[index].Source)
-			o.Log.Debug("destination %s ", images[index].Destination)
-			go func(ctx context.Context, src, dest string, opts *mirror.CopyOptions, writer bufio.Writer) {
+			log := clog.With(o.Log, "image", images[index].Source, "batch", strconv.Itoa(i))
+			log.Debug("source %s ", images[index].Source)
+			log.Debug("destination %s ", images[index].Destination)
+			// each image has its own log file (echoed live to the console)
+			lf, err := runlog.Open(log, opts.Global.LogsDir, runlog.FileName(images[index].Source), opts.Global.Quiet)
+			if err != nil {
+				log.Error("[Worker] %v", err)
+			}
+			go func(ctx context.Context, img v1alpha3.CopyImageSchema, opts *mirror.CopyOptions, lf *runlog.File) {
 				defer wg.Done()
-				err := o.Mirror.Run(ctx, src, dest, "copy", opts, writer)
+				defer lf.Close()
+				opts.Result = &mirror.CopyResult{}
+				start := time.Now()
+				err := o.Mirror.Run(ctx, img.Source, img.Destination, "copy", opts, *lf.Writer())
+				o.addResult(img, *opts.Result, time.Since(start), err)
 				if err != nil {
+					o.mu.Lock()
 					errArray = append(errArray, err)
+					o.mu.Unlock()
 				}
-			}(ctx, images[index].Source, images[index].Destination, &opts, *writer)
+			}(ctx, images[index], imageCopyOptions(opts, images[index]), lf)
 		}
 		wg.Wait()
-		// rather than use defer Close we intentianally close the log files
-		for _, f := range f {
-			f.Close()
-		}
 		o.Log.Info("completed batch %d", i)
 		if b.Count > 1 {
 			wg.Add(BATCH_SIZE)
@@ -117,29 +118,59 @@ func (o *Batch) Worker(ctx context.Context, images []v1alpha3.CopyImageSchema, o
 		if err != nil {
 			return err
 		}
-		// output the logs to console
-		if !opts.Global.Quiet {
-			consoleLogFromFile(o.Log)
-		}
 		o.Log.Info("[Worker] successfully completed all batches")
 	}
 	return nil
 }
 
-// consoleLogFromFile
-func consoleLogFromFile(log clog.PluggableLoggerInterface) {
-	dir, _ := os.ReadDir("logs")
-	for _, f := range dir {
-		if strings.Contains(f.Name(), "worker") {
-			log.Debug("[batch] %s ", f.Name())
-			data, _ := os.ReadFile("logs/" + f.Name())
-			lines := strings.Split(string(data), "\n")
-			for _, s := range lines {
-				if len(s) > 0 {
-					// clean the line
-					log.Debug("%s ", strings.ToLower(s))
-				}
-			}
-		}
+// Results - returns the outcome of the image copies
+func (o *Batch) Results() []report.Image {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]report.Image{}, o.results...)
+}
+
+// addResult - records the outcome of an image copy
+func (o *Batch) addResult(img v1alpha3.CopyImageSchema, result mirror.CopyResult, duration time.Duration, err error) {
+	res := report.Image{
+		Type:        img.Origin,
+		Source:      img.Source,
+		Destination: img.Destination,
+		Digest:      result.Digest,
+		Size:        result.Size,
+		Duration:    duration.Seconds(),
+		Retries:     result.Retries,
+		Status:      report.StatusSuccess,
+	}
+	if err != nil {
+		res.Status = report.StatusFailed
+		res.Error = err.Error()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.results = append(o.results, res)
+}
+
+// imageCopyOptions - returns the copy options for a single image
+// an image level multi-arch setting overrides the global setting, both
+// the image level multi-arch and preserve digests settings preserve digests
+// image level architectures copy only those instances of a manifest list
+func imageCopyOptions(opts mirror.CopyOptions, img v1alpha3.CopyImageSchema) *mirror.CopyOptions {
+	if len(img.MultiArch) == 0 && !img.PreserveDigests && len(img.Architectures) == 0 {
+		return &opts
+	}
+	imgOpts := opts
+	// the index is trimmed to the architectures (the digest changes)
+	if len(img.Architectures) > 0 {
+		imgOpts.All = false
+		imgOpts.MultiArch = ""
+		imgOpts.Architectures = img.Architectures
+		return &imgOpts
+	}
+	if len(img.MultiArch) > 0 {
+		imgOpts.All = false
+		imgOpts.MultiArch = img.MultiArch
 	}
+	imgOpts.PreserveDigests = true
+	return &imgOpts
 }
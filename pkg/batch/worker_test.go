@@ -50,6 +50,31 @@ func TestWorker(t *testing.T) {
 		if err != nil {
 			t.Fatal("should pass")
 		}
+		res := w.Results()
+		if len(res) != len(relatedImages) || res[0].Status != "success" {
+			t.Fatalf("should record the result of each image %v", res)
+		}
+	})
+}
+
+func TestImageCopyOptions(t *testing.T) {
+	opts := mirror.CopyOptions{All: true}
+
+	t.Run("Testing imageCopyOptions multi-arch : should pass", func(t *testing.T) {
+		res := imageCopyOptions(opts, v1alpha3.CopyImageSchema{MultiArch: "all"})
+		if res.All || res.MultiArch != "all" || !res.PreserveDigests {
+			t.Fatalf("should override the global options %v", res)
+		}
+	})
+
+	t.Run("Testing imageCopyOptions architectures : should pass", func(t *testing.T) {
+		res := imageCopyOptions(opts, v1alpha3.CopyImageSchema{Architectures: []string{"amd64", "arm64"}})
+		if res.All || len(res.MultiArch) > 0 || res.PreserveDigests || len(res.Architectures) != 2 {
+			t.Fatalf("should copy only the architectures %v", res)
+		}
+		if len(opts.Architectures) > 0 {
+			t.Fatalf("should not change the global options")
+		}
 	})
 }
 
@@ -0,0 +1,379 @@
+package blobstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/manifest"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	StoreDir      string = "blob-store"
+	ModeGlobal    string = "global"
+	ModeType      string = "type"
+	indexFile     string = "index.json"
+	layoutFile    string = "oci-layout"
+	blobsDir      string = "blobs"
+	stagingDir    string = "staging"
+	dirManifest   string = "manifest.json"
+	layoutVersion string = `{"imageLayoutVersion": "1.0.0"}`
+	tmpSuffix     string = ".blobstore"
+	ociProtocol   string = "oci:"
+	dirProtocol   string = "dir:"
+	errMsg        string = "[BlobStore] %v "
+)
+
+// StoreInterface - the cached images of a working dir in an OCI image layout with shared blobs
+type StoreInterface interface {
+	Add(ref, dir string) error
+	GC() (int, error)
+}
+
+// New - returns a store for the OCI image layout in dir
+func New(log clog.PluggableLoggerInterface, dir string) StoreInterface {
+	return &Store{Log: log, Dir: dir}
+}
+
+// Store - an OCI image layout, each image is referenced by its cache path (relative to the working dir)
+// in the org.opencontainers.image.ref.name annotation of the index, a blob is only stored once
+type Store struct {
+	Log clog.PluggableLoggerInterface
+	Dir string
+	mu  sync.Mutex
+}
+
+// Image - an image of a store, the source is the oci: reference of the image in the store layout
+type Image struct {
+	Ref       string
+	Source    string
+	MediaType string
+}
+
+// IsList - true if the image is a manifest list (or an oci index)
+func (o Image) IsList() bool {
+	return manifest.MIMETypeIsMultiImage(o.MediaType)
+}
+
+// Add - adds the dir: image in dir to the store with the ref name
+// the digest of each blob is verified before it is moved to the store (the blobs already stored are dropped),
+// an image with the same ref name is replaced and the dir is removed
+func (o *Store) Add(ref, dir string) error {
+	if err := os.MkdirAll(filepath.Join(o.Dir, blobsDir, digest.Canonical.String()), 0755); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	for _, f := range files {
+		// the blobs (<hash>) and the instances of a manifest list (<hash>.manifest.json)
+		d := digest.NewDigestFromEncoded(digest.Canonical, strings.TrimSuffix(f.Name(), "."+dirManifest))
+		if f.IsDir() || d.Validate() != nil {
+			continue
+		}
+		if err := o.importBlob(filepath.Join(dir, f.Name()), d); err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+	mb, err := os.ReadFile(filepath.Join(dir, dirManifest))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	d := digest.FromBytes(mb)
+	if err := o.importBlob(filepath.Join(dir, dirManifest), d); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	desc := imgspecv1.Descriptor{
+		MediaType:   manifest.GuessMIMEType(mb),
+		Digest:      d,
+		Size:        int64(len(mb)),
+		Annotations: map[string]string{imgspecv1.AnnotationRefName: ref},
+	}
+	if err := o.addDescriptor(desc); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	o.Log.Trace("blob store %s added %s (%s)", o.Dir, ref, d)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// GC - removes the store blobs that are not referenced by the index (and the staging dirs left by failed copies),
+// returns the number of blobs removed
+func (o *Store) GC() (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := os.RemoveAll(filepath.Join(o.Dir, stagingDir)); err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+	index, err := readIndex(o.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+	refs := make(map[digest.Digest]bool)
+	for _, desc := range index.Manifests {
+		o.mark(desc.Digest, refs)
+	}
+	blobs := filepath.Join(o.Dir, blobsDir, digest.Canonical.String())
+	files, err := os.ReadDir(blobs)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+	removed := 0
+	for _, f := range files {
+		if refs[digest.NewDigestFromEncoded(digest.Canonical, f.Name())] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(blobs, f.Name())); err != nil {
+			return removed, fmt.Errorf(errMsg, err)
+		}
+		o.Log.Trace("removed blob %s ", f.Name())
+		removed++
+	}
+	return removed, nil
+}
+
+// mark - marks a manifest (or index) and the blobs it references
+func (o *Store) mark(d digest.Digest, refs map[digest.Digest]bool) {
+	if refs[d] {
+		return
+	}
+	refs[d] = true
+	mb, err := os.ReadFile(o.blobPath(d))
+	if err != nil {
+		o.Log.Debug("blob store manifest %s : %v", d, err)
+		return
+	}
+	mt := manifest.GuessMIMEType(mb)
+	if manifest.MIMETypeIsMultiImage(mt) {
+		list, err := manifest.ListFromBlob(mb, mt)
+		if err != nil {
+			o.Log.Debug("blob store manifest list %s : %v", d, err)
+			return
+		}
+		for _, instance := range list.Instances() {
+			o.mark(instance, refs)
+		}
+		return
+	}
+	m, err := manifest.FromBlob(mb, mt)
+	if err != nil {
+		o.Log.Debug("blob store manifest %s : %v", d, err)
+		return
+	}
+	refs[m.ConfigInfo().Digest] = true
+	for _, layer := range m.LayerInfos() {
+		refs[layer.Digest] = true
+	}
+}
+
+// importBlob - moves the file to the store blob of the digest (unless it is already stored)
+// the content of the file must match the digest, a corrupted blob is never stored
+func (o *Store) importBlob(file string, d digest.Digest) error {
+	blob := o.blobPath(d)
+	if _, err := os.Stat(blob); err == nil {
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	actual, err := d.Algorithm().FromReader(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if actual != d {
+		return fmt.Errorf("blob %s digest mismatch (%s)", file, actual)
+	}
+	return os.Rename(file, blob)
+}
+
+// addDescriptor - adds the descriptor to the index (replacing the image with the same ref name)
+func (o *Store) addDescriptor(desc imgspecv1.Descriptor) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	index, err := readIndex(o.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		index = imgspecv1.Index{Versioned: imgspecs.Versioned{SchemaVersion: 2}, MediaType: imgspecv1.MediaTypeImageIndex}
+	} else if err != nil {
+		return err
+	}
+	manifests := []imgspecv1.Descriptor{}
+	for _, m := range index.Manifests {
+		if m.Annotations[imgspecv1.AnnotationRefName] != desc.Annotations[imgspecv1.AnnotationRefName] {
+			manifests = append(manifests, m)
+		}
+	}
+	index.Manifests = append(manifests, desc)
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(o.Dir, layoutFile)); err != nil {
+		if err := os.WriteFile(filepath.Join(o.Dir, layoutFile), []byte(layoutVersion), 0644); err != nil {
+			return err
+		}
+	}
+	tmp := filepath.Join(o.Dir, indexFile+tmpSuffix)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(o.Dir, indexFile))
+}
+
+func (o *Store) blobPath(d digest.Digest) string {
+	return filepath.Join(o.Dir, blobsDir, d.Algorithm().String(), d.Encoded())
+}
+
+// Images - returns the images of the stores of a working dir (the global store and the stores per image type)
+// with a ref name in the prefix dir (i.e operator-images/redhat-operator-index/v4.14), all images if empty
+func Images(workingDir, prefix string) ([]Image, error) {
+	stores := []string{filepath.Join(workingDir, StoreDir)}
+	typeStores, err := filepath.Glob(filepath.Join(workingDir, StoreDir, "*", indexFile))
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	for _, index := range typeStores {
+		stores = append(stores, filepath.Dir(index))
+	}
+	prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+	var images []Image
+	for _, store := range stores {
+		index, err := readIndex(store)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf(errMsg, err)
+		}
+		for _, desc := range index.Manifests {
+			ref := desc.Annotations[imgspecv1.AnnotationRefName]
+			if len(ref) == 0 || (len(prefix) > 0 && ref != prefix && !strings.HasPrefix(ref, prefix+"/")) {
+				continue
+			}
+			images = append(images, Image{Ref: ref, Source: Destination(store, ref), MediaType: desc.MediaType})
+		}
+	}
+	return images, nil
+}
+
+// Refs - returns the ref names of the images of the stores of a working dir (see Images)
+func Refs(workingDir, prefix string) (map[string]bool, error) {
+	images, err := Images(workingDir, prefix)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]bool)
+	for _, img := range images {
+		refs[img.Ref] = true
+	}
+	return refs, nil
+}
+
+// Split - splits a path at its (last) image type dir
+// i.e working-dir/mirror/operator-images/a/b -> working-dir/mirror, operator-images/a/b
+func Split(path, typeDir string) (string, string, bool) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] != typeDir {
+			continue
+		}
+		workingDir := strings.Join(parts[:i], "/")
+		switch {
+		case i == 0:
+			workingDir = "."
+		case len(workingDir) == 0:
+			workingDir = "/"
+		}
+		return workingDir, strings.Join(parts[i:], "/"), true
+	}
+	return "", "", false
+}
+
+// Destination - the oci: reference of an image in the store layout
+func Destination(storeDir, ref string) string {
+	return ociProtocol + storeDir + ":" + ref
+}
+
+// NewMirror - returns a mirror that adds the images with a store destination (see Destination) to the store
+func NewMirror(log clog.PluggableLoggerInterface, m mirror.MirrorInterface) mirror.MirrorInterface {
+	return &Mirror{Log: log, Mirror: m, stores: make(map[string]StoreInterface)}
+}
+
+// Mirror - copies an image with a store destination to a dir: staging dir of the store (the manifest is
+// kept as is, the oci layout only supports oci manifests) and adds it to the store, other images are copied as is
+type Mirror struct {
+	Log    clog.PluggableLoggerInterface
+	Mirror mirror.MirrorInterface
+	mu     sync.Mutex
+	stores map[string]StoreInterface
+}
+
+// Run - mirror interface implementation
+func (o *Mirror) Run(ctx context.Context, src, dest, mode string, opts *mirror.CopyOptions, stdout bufio.Writer) error {
+	storeDir, ref, ok := storeReference(dest)
+	if mode == "delete" || !ok {
+		return o.Mirror.Run(ctx, src, dest, mode, opts, stdout)
+	}
+	staging := filepath.Join(storeDir, stagingDir, digest.FromString(ref).Encoded())
+	if err := os.RemoveAll(staging); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	if err := o.Mirror.Run(ctx, src, dirProtocol+staging, mode, opts, stdout); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	return o.store(storeDir).Add(ref, staging)
+}
+
+// store - one store per dir (the index updates of the batch workers are serialized)
+func (o *Mirror) store(dir string) StoreInterface {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.stores[dir]; !ok {
+		o.stores[dir] = New(o.Log, dir)
+	}
+	return o.stores[dir]
+}
+
+// storeReference - the store dir and the ref name of a store destination
+func storeReference(dest string) (string, string, bool) {
+	if !strings.HasPrefix(dest, ociProtocol) {
+		return "", "", false
+	}
+	dir, ref, ok := strings.Cut(strings.TrimPrefix(dest, ociProtocol), ":")
+	if !ok || len(ref) == 0 || (filepath.Base(dir) != StoreDir && filepath.Base(filepath.Dir(dir)) != StoreDir) {
+		return "", "", false
+	}
+	return dir, ref, true
+}
+
+func readIndex(dir string) (imgspecv1.Index, error) {
+	var index imgspecv1.Index
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("%s : %v", dir, err)
+	}
+	return index, nil
+}
@@ -0,0 +1,228 @@
+package blobstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	log := clog.New("trace")
+	root := t.TempDir()
+	storeDir := filepath.Join(root, StoreDir)
+	store := New(log, storeDir)
+
+	// the base layer is shared by all the images
+	base := []byte("base layer")
+	stagingA := filepath.Join(storeDir, stagingDir, "a")
+	stagingB := filepath.Join(storeDir, stagingDir, "b")
+	manifestA := writeDirImage(t, stagingA, base, []byte("layer a"))
+	manifestB := writeDirImage(t, stagingB, base, []byte("layer b"))
+
+	t.Run("Testing Add : should pass", func(t *testing.T) {
+		require.NoError(t, store.Add("additional-images/team/a/v1", stagingA))
+		require.NoError(t, store.Add("release-images/ocp-release/4.14.1-x86_64/images/etcd", stagingB))
+
+		require.Equal(t, map[string]digest.Digest{
+			"additional-images/team/a/v1":                          manifestA,
+			"release-images/ocp-release/4.14.1-x86_64/images/etcd": manifestB,
+		}, indexRefs(t, storeDir))
+
+		// the base layer is stored once, the staging dirs are removed
+		blobs, err := os.ReadDir(filepath.Join(storeDir, "blobs/sha256"))
+		require.NoError(t, err)
+		// 2 manifests, 2 configs, 2 layers and the base layer
+		require.Equal(t, 7, len(blobs))
+		for _, dir := range []string{stagingA, stagingB} {
+			_, err := os.Stat(dir)
+			require.True(t, os.IsNotExist(err))
+		}
+		_, err = os.Stat(filepath.Join(storeDir, layoutFile))
+		require.NoError(t, err)
+	})
+
+	t.Run("Testing Add (corrupted blob) : should fail", func(t *testing.T) {
+		staging := filepath.Join(storeDir, stagingDir, "c")
+		layer := []byte("layer c")
+		writeDirImage(t, staging, base, layer)
+		require.NoError(t, os.WriteFile(filepath.Join(staging, digest.FromBytes(layer).Encoded()), []byte("corrupted"), 0644))
+		err := store.Add("additional-images/team/c/v1", staging)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "digest mismatch")
+		_, err = os.Stat(filepath.Join(storeDir, "blobs/sha256", digest.FromBytes(layer).Encoded()))
+		require.True(t, os.IsNotExist(err))
+		require.NotContains(t, indexRefs(t, storeDir), "additional-images/team/c/v1")
+	})
+
+	t.Run("Testing GC : should pass", func(t *testing.T) {
+		// the image a is replaced, an unknown blob is unreferenced
+		staging := filepath.Join(storeDir, stagingDir, "a2")
+		manifestA2 := writeDirImage(t, staging, base, []byte("layer a2"))
+		require.NoError(t, store.Add("additional-images/team/a/v1", staging))
+		require.Equal(t, manifestA2, indexRefs(t, storeDir)["additional-images/team/a/v1"])
+		require.NoError(t, os.WriteFile(filepath.Join(storeDir, "blobs/sha256", digest.FromString("x").Encoded()), []byte("x"), 0644))
+
+		removed, err := store.GC()
+		require.NoError(t, err)
+		// manifest, config and layer of the old image a and the unknown blob
+		require.Equal(t, 4, removed)
+		_, err = os.Stat(filepath.Join(storeDir, "blobs/sha256", digest.FromBytes(base).Encoded()))
+		require.NoError(t, err)
+		// the staging dirs left by failed copies are removed
+		_, err = os.Stat(filepath.Join(storeDir, stagingDir))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Testing GC (no index) : should pass", func(t *testing.T) {
+		removed, err := New(log, t.TempDir()).GC()
+		require.NoError(t, err)
+		require.Equal(t, 0, removed)
+	})
+
+	t.Run("Testing Images : should pass", func(t *testing.T) {
+		// a store per image type
+		typeStore := filepath.Join(storeDir, "helm-images")
+		staging := filepath.Join(typeStore, stagingDir, "h")
+		writeDirImage(t, staging, base)
+		require.NoError(t, New(log, typeStore).Add("helm-images/team/h/v1", staging))
+
+		images, err := Images(root, "additional-images")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(images))
+		require.Equal(t, "additional-images/team/a/v1", images[0].Ref)
+		require.Equal(t, "oci:"+storeDir+":additional-images/team/a/v1", images[0].Source)
+		require.Equal(t, imgspecv1.MediaTypeImageManifest, images[0].MediaType)
+		require.False(t, images[0].IsList())
+
+		refs, err := Refs(root, "")
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{
+			"additional-images/team/a/v1":                          true,
+			"release-images/ocp-release/4.14.1-x86_64/images/etcd": true,
+			"helm-images/team/h/v1":                                true,
+		}, refs)
+
+		images, err = Images(t.TempDir(), "")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(images))
+	})
+
+	t.Run("Testing Split : should pass", func(t *testing.T) {
+		wd, rel, ok := Split("working-dir/mirror/operator-images/redhat-operator-index/v4.14/", "operator-images")
+		require.True(t, ok)
+		require.Equal(t, "working-dir/mirror", wd)
+		require.Equal(t, "operator-images/redhat-operator-index/v4.14", rel)
+		wd, rel, ok = Split("/additional-images", "additional-images")
+		require.True(t, ok)
+		require.Equal(t, "/", wd)
+		require.Equal(t, "additional-images", rel)
+		_, _, ok = Split("working-dir/mirror", "additional-images")
+		require.False(t, ok)
+	})
+}
+
+func TestMirror(t *testing.T) {
+	log := clog.New("trace")
+	root := t.TempDir()
+	storeDir := filepath.Join(root, StoreDir)
+	inner := &mockMirror{T: t}
+	m := NewMirror(log, inner)
+
+	t.Run("Testing Mirror (store destination) : should pass", func(t *testing.T) {
+		err := m.Run(context.Background(), "docker://quay.io/team/a:v1", Destination(storeDir, "additional-images/team/a/v1"), "copy", &mirror.CopyOptions{}, bufio.Writer{})
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(inner.Dest, "dir:"+filepath.Join(storeDir, stagingDir)))
+		require.Contains(t, indexRefs(t, storeDir), "additional-images/team/a/v1")
+	})
+
+	t.Run("Testing Mirror (other destination) : should pass", func(t *testing.T) {
+		dest := "oci:" + filepath.Join(root, "additional-images/team/b/v1")
+		err := m.Run(context.Background(), "docker://quay.io/team/b:v1", dest, "copy", &mirror.CopyOptions{}, bufio.Writer{})
+		require.NoError(t, err)
+		require.Equal(t, dest, inner.Dest)
+	})
+
+	t.Run("Testing Mirror (copy error) : should fail", func(t *testing.T) {
+		inner.Fail = true
+		defer func() { inner.Fail = false }()
+		err := m.Run(context.Background(), "docker://quay.io/team/c:v1", Destination(storeDir, "additional-images/team/c/v1"), "copy", &mirror.CopyOptions{}, bufio.Writer{})
+		require.Error(t, err)
+		require.NotContains(t, indexRefs(t, storeDir), "additional-images/team/c/v1")
+		entries, err := os.ReadDir(filepath.Join(storeDir, stagingDir))
+		require.NoError(t, err)
+		require.Equal(t, 0, len(entries))
+	})
+}
+
+// writeDirImage - writes a dir: image, returns the manifest digest
+func writeDirImage(t *testing.T, dir string, layers ...[]byte) digest.Digest {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	mb := imageManifest(t, dir, func(data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, digest.FromBytes(data).Encoded()), data, 0644))
+	}, layers...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, dirManifest), mb, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "version"), []byte("Directory Transport Version: 1.1\n"), 0644))
+	return digest.FromBytes(mb)
+}
+
+// imageManifest - writes the config and layers with the blob writer, returns the manifest
+func imageManifest(t *testing.T, name string, blob func([]byte), layers ...[]byte) []byte {
+	config := []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]},"config":{"Labels":{"name":"` + name + `"}}}`)
+	blob(config)
+	m := imgspecv1.Manifest{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config:    imgspecv1.Descriptor{MediaType: imgspecv1.MediaTypeImageConfig, Digest: digest.FromBytes(config), Size: int64(len(config))},
+	}
+	for _, layer := range layers {
+		blob(layer)
+		m.Layers = append(m.Layers, imgspecv1.Descriptor{MediaType: imgspecv1.MediaTypeImageLayerGzip, Digest: digest.FromBytes(layer), Size: int64(len(layer))})
+	}
+	mb, err := json.Marshal(m)
+	require.NoError(t, err)
+	return mb
+}
+
+// indexRefs - the manifest digests of the store index by ref name
+func indexRefs(t *testing.T, dir string) map[string]digest.Digest {
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	require.NoError(t, err)
+	var index imgspecv1.Index
+	require.NoError(t, json.Unmarshal(data, &index))
+	refs := make(map[string]digest.Digest)
+	for _, desc := range index.Manifests {
+		refs[desc.Annotations[imgspecv1.AnnotationRefName]] = desc.Digest
+	}
+	return refs
+}
+
+// setup mocks
+
+// mockMirror - writes a dir: image to the destination (dir: only)
+type mockMirror struct {
+	T    *testing.T
+	Dest string
+	Fail bool
+}
+
+func (o *mockMirror) Run(ctx context.Context, src, dest, mode string, opts *mirror.CopyOptions, stdout bufio.Writer) error {
+	o.Dest = dest
+	if strings.HasPrefix(dest, "dir:") {
+		writeDirImage(o.T, strings.TrimPrefix(dest, "dir:"), []byte("layer "+src))
+	}
+	if o.Fail {
+		return os.ErrInvalid
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package checksum
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+const (
+	SumsFile      string = "SHA256SUMS"
+	SignatureFile string = "SHA256SUMS.asc"
+	concurrency   int    = 8
+	errMsg        string = "[Checksum] %v "
+)
+
+// Write - writes the sha256 of every file in dir to the sums file of dir, one `<sha256>  <path>` line per file
+// (the sha256sum format, paths relative to dir and sorted) so it can also be checked with `sha256sum -c`
+// returns the number of files
+func Write(dir string) (int, error) {
+	files, err := listFiles(dir)
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	sums := make([]string, len(files))
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			sums[i], errs[i] = sha256File(filepath.Join(dir, filepath.FromSlash(file)))
+		}(i, file)
+	}
+	wg.Wait()
+
+	var buf bytes.Buffer
+	for i, file := range files {
+		if errs[i] != nil {
+			return 0, fmt.Errorf(errMsg, errs[i])
+		}
+		fmt.Fprintf(&buf, "%s  %s\n", sums[i], file)
+	}
+	// a stale signature doesn't match the new sums
+	if err := os.Remove(filepath.Join(dir, SignatureFile)); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+	if err := writeFile(filepath.Join(dir, SumsFile), buf.Bytes()); err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+	return len(files), nil
+}
+
+// Sign - writes an armored detached signature of the sums file of dir (gpg --verify compatible)
+// with the first private key of the armored keyring in keyFile, an encrypted key is decrypted with the passphrase
+func Sign(dir, keyFile string, passphrase []byte) error {
+	keyring, err := readKeyRing(keyFile)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	var signer *openpgp.Entity
+	for _, entity := range keyring {
+		if entity.PrivateKey != nil {
+			signer = entity
+			break
+		}
+	}
+	if signer == nil {
+		return fmt.Errorf(errMsg, "no private key found in "+keyFile)
+	}
+	if signer.PrivateKey.Encrypted {
+		if err := signer.PrivateKey.Decrypt(passphrase); err != nil {
+			return fmt.Errorf(errMsg, fmt.Sprintf("decrypt private key : %v", err))
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, SumsFile))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, signer, bytes.NewReader(data), nil); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	if err := writeFile(filepath.Join(dir, SignatureFile), sig.Bytes()); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// Verify - checks the sha256 of every file listed in the sums file of dir, a file of dir
+// that is not listed (added after the sums were written) also fails the verification
+// when keyFile (an armored public keyring) is set the detached signature must exist and be valid
+// returns the number of files verified
+func Verify(dir, keyFile string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SumsFile))
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+	if len(keyFile) > 0 {
+		keyring, err := readKeyRing(keyFile)
+		if err != nil {
+			return 0, fmt.Errorf(errMsg, err)
+		}
+		sig, err := os.Open(filepath.Join(dir, SignatureFile))
+		if err != nil {
+			return 0, fmt.Errorf(errMsg, err)
+		}
+		defer sig.Close()
+		if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(data), sig); err != nil {
+			return 0, fmt.Errorf(errMsg, fmt.Sprintf("signature %s : %v", filepath.Join(dir, SignatureFile), err))
+		}
+	}
+
+	var failed []string
+	listed := make(map[string]bool)
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			continue
+		}
+		sum, file, ok := strings.Cut(line, "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return count, fmt.Errorf(errMsg, fmt.Sprintf("%s malformed line %q", SumsFile, line))
+		}
+		listed[file] = true
+		actual, err := sha256File(filepath.Join(dir, filepath.FromSlash(file)))
+		switch {
+		case err != nil:
+			failed = append(failed, fmt.Sprintf("%s (%v)", file, err))
+		case actual != sum:
+			failed = append(failed, fmt.Sprintf("%s (checksum mismatch)", file))
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf(errMsg, err)
+	}
+	files, err := listFiles(dir)
+	if err != nil {
+		return count, fmt.Errorf(errMsg, err)
+	}
+	for _, file := range files {
+		if !listed[file] {
+			failed = append(failed, fmt.Sprintf("%s (not listed)", file))
+		}
+	}
+	if len(failed) > 0 {
+		return count, fmt.Errorf(errMsg, fmt.Sprintf("%d of %d files failed verification : %s", len(failed), count, strings.Join(failed, ", ")))
+	}
+	return count, nil
+}
+
+// Find - returns the dirs with a sums file, dir itself and its sub dirs (i.e working-dir/<destination>)
+// or else the nearest parent of dir with a sums file (i.e working-dir/<destination>/release-images)
+func Find(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*", SumsFile))
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	var dirs []string
+	if _, err := os.Stat(filepath.Join(dir, SumsFile)); err == nil {
+		dirs = append(dirs, dir)
+	}
+	for _, match := range matches {
+		dirs = append(dirs, filepath.Dir(match))
+	}
+	if len(dirs) > 0 {
+		return dirs, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	for parent := filepath.Dir(abs); parent != filepath.Dir(parent); parent = filepath.Dir(parent) {
+		if _, err := os.Stat(filepath.Join(parent, SumsFile)); err == nil {
+			return []string{parent}, nil
+		}
+	}
+	return nil, nil
+}
+
+// listFiles - the files of dir (paths relative to dir and sorted) except the sums file and its signature
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == SumsFile || rel == SignatureFile {
+			return nil
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readKeyRing(keyFile string) (openpgp.EntityList, error) {
+	f, err := os.Open(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return openpgp.ReadArmoredKeyRing(f)
+}
+
+// writeFile - writes to a temporary file first so an interrupted run doesn't leave a truncated file
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,114 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestChecksum(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "release-images/4.14.1/images/etcd"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release-images/4.14.1/images/etcd/manifest.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release-images/4.14.1/images/etcd/version"), []byte("1.1"), 0644))
+	privateKey, publicKey := writeKeys(t, t.TempDir())
+
+	t.Run("Testing Write and Verify : should pass", func(t *testing.T) {
+		count, err := Write(dir)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+		data, err := os.ReadFile(filepath.Join(dir, SumsFile))
+		require.NoError(t, err)
+		require.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a  release-images/4.14.1/images/etcd/manifest.json\n"+
+			"b05e244762b1e472be89a93800cc3ee326743cecb55984bf12813addb8de66d0  release-images/4.14.1/images/etcd/version\n", string(data))
+		count, err = Verify(dir, "")
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("Testing Sign and Verify : should pass", func(t *testing.T) {
+		require.NoError(t, Sign(dir, privateKey, nil))
+		_, err := Verify(dir, publicKey)
+		require.NoError(t, err)
+	})
+
+	t.Run("Testing Verify (tampered) : should fail", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "release-images/4.14.1/images/etcd/version"), []byte("1.2"), 0644))
+		_, err := Verify(dir, "")
+		require.ErrorContains(t, err, "version (checksum mismatch)")
+
+		// the sums are rewritten so the signature no longer matches
+		old := filepath.Join(t.TempDir(), SignatureFile)
+		require.NoError(t, os.Rename(filepath.Join(dir, SignatureFile), old))
+		_, err = Write(dir)
+		require.NoError(t, err)
+		require.NoError(t, os.Rename(old, filepath.Join(dir, SignatureFile)))
+		_, err = Verify(dir, "")
+		require.NoError(t, err)
+		_, err = Verify(dir, publicKey)
+		require.ErrorContains(t, err, "signature")
+	})
+
+	t.Run("Testing Verify (not listed) : should fail", func(t *testing.T) {
+		_, err := Write(dir)
+		require.NoError(t, err)
+		extra := filepath.Join(dir, "release-images/4.14.1/images/etcd/extra")
+		require.NoError(t, os.WriteFile(extra, []byte("x"), 0644))
+		defer os.Remove(extra)
+		_, err = Verify(dir, "")
+		require.ErrorContains(t, err, "release-images/4.14.1/images/etcd/extra (not listed)")
+	})
+
+	t.Run("Testing Verify (missing signature) : should fail", func(t *testing.T) {
+		_, err := Write(dir)
+		require.NoError(t, err)
+		_, err = Verify(dir, publicKey)
+		require.Error(t, err)
+		require.Error(t, Sign(dir, publicKey, nil))
+	})
+
+	t.Run("Testing Find : should pass", func(t *testing.T) {
+		root := filepath.Dir(dir)
+		dirs, err := Find(root)
+		require.NoError(t, err)
+		require.Contains(t, dirs, dir)
+
+		dirs, err = Find(filepath.Join(dir, "release-images/4.14.1"))
+		require.NoError(t, err)
+		require.Equal(t, []string{dir}, dirs)
+
+		dirs, err = Find(t.TempDir())
+		require.NoError(t, err)
+		require.Empty(t, dirs)
+	})
+}
+
+// writeKeys - writes an armored private and public keyring, returns the file names
+func writeKeys(t *testing.T, dir string) (string, string) {
+	entity, err := openpgp.NewEntity("mirror", "test", "mirror@example.com", nil)
+	require.NoError(t, err)
+	privateKey := filepath.Join(dir, "private.asc")
+	publicKey := filepath.Join(dir, "public.asc")
+	for _, key := range []struct {
+		file      string
+		blockType string
+		private   bool
+	}{{privateKey, openpgp.PrivateKeyType, true}, {publicKey, openpgp.PublicKeyType, false}} {
+		f, err := os.Create(key.file)
+		require.NoError(t, err)
+		w, err := armor.Encode(f, key.blockType, nil)
+		require.NoError(t, err)
+		if key.private {
+			require.NoError(t, entity.SerializePrivate(w, nil))
+		} else {
+			require.NoError(t, entity.Serialize(w))
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, f.Close())
+	}
+	return privateKey, publicKey
+}
@@ -13,14 +13,23 @@ import (
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/batch"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/checksum"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/config"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/diff"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/diskspace"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/filter"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/helm"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/operator"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/release"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/report"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/runlog"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -33,9 +42,12 @@ const (
 	logsDir                 string = "logs"
 	workingDir              string = "working-dir"
 	additionalImages        string = "additional-images"
+	operatorImageDir        string = "operator-images"
+	helmImageDir            string = "helm-images"
 	releaseImageExtractDir  string = "hold-release"
 	operatorImageExtractDir string = "hold-operator"
 	signaturesDir           string = "signatures"
+	diskToMirrorConfig      string = "disk-to-mirror-config.yaml"
 )
 
 var (
@@ -67,10 +79,14 @@ type ExecutorSchema struct {
 	Operator         operator.CollectorInterface
 	Release          release.CollectorInterface
 	AdditionalImages additional.CollectorInterface
+	Helm             helm.CollectorInterface
 	Mirror           mirror.MirrorInterface
 	Manifest         manifest.ManifestInterface
 	Batch            batch.BatchInterface
+	Digest           mirror.DigestInterface
+	Blobs            diskspace.BlobsInterface
 	Diff             diff.DiffInterface
+	Report           *report.Report
 }
 
 // NewMirrorCmd - cobra entry point
@@ -110,6 +126,10 @@ func NewMirrorCmd(log clog.PluggableLoggerInterface) *cobra.Command {
 		Args:          cobra.MinimumNArgs(1),
 		SilenceErrors: false,
 		SilenceUsage:  false,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the log format applies to the subcommands too
+			return log.Format(opts.Global.LogFormat)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			err := ex.Validate(args)
 			if err != nil {
@@ -128,30 +148,60 @@ func NewMirrorCmd(log clog.PluggableLoggerInterface) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringVar(&opts.Global.ConfigPath, "config", "", "Path to imageset configuration file")
+	cmd.PersistentFlags().StringVar(&opts.Global.LogFormat, "log-format", clog.FormatText, "Log format one of (text, json), json logs a JSON object per line")
 	cmd.Flags().StringVar(&opts.Global.LogLevel, "loglevel", "info", "Log level one of (info, debug, trace, error)")
 	cmd.Flags().StringVar(&opts.Global.Dir, "dir", "working-dir", "Assets directory")
 	cmd.Flags().BoolVarP(&opts.Global.Quiet, "quiet", "q", false, "enable detailed logging when copying images")
 	cmd.Flags().BoolVarP(&opts.Global.Force, "force", "f", false, "force the copy and mirror functionality")
+	cmd.Flags().BoolVar(&opts.Global.DryRun, "dry-run", false, "Estimate the disk space and list the images to copy without copying them")
+	cmd.Flags().StringVar(&opts.Global.BlobStore, "blob-store", "", "Write the cached images to an OCI layout with shared blobs for all images (global) or per image type (type)")
+	cmd.Flags().StringVar(&opts.Global.From, "from", "", "Discover the images to mirror in the working dir (diskToMirror), the --config flag is then optional")
+	cmd.Flags().StringVar(&opts.Global.ReleaseFrom, "release-from", "", "Discover the releases in this dir (diskToMirror, overrides --from)")
+	cmd.Flags().StringVar(&opts.Global.OperatorsFrom, "operators-from", "", "Discover the catalogs in this dir (diskToMirror, overrides --from)")
+	cmd.Flags().StringVar(&opts.Global.AdditionalFrom, "additional-from", "", "Discover the additional images in this dir (diskToMirror, overrides --from)")
+	cmd.Flags().StringVar(&opts.Global.SignKey, "sign-key", "", "Armored private key to sign the SHA256SUMS of the working dir (mirrorToDisk)")
+	cmd.Flags().StringVar(&opts.Global.SignPassphraseFile, "sign-passphrase-file", "", "File with the passphrase of the sign key")
+	cmd.Flags().StringVar(&opts.Global.VerifyKey, "verify-key", "", "Armored public key the SHA256SUMS signature must be valid for (diskToMirror)")
+	cmd.Flags().BoolVar(&opts.Global.SkipChecksums, "skip-checksums", false, "Push even if the SHA256SUMS of the working dir fail to verify (diskToMirror)")
+	cmd.Flags().IntVar(&opts.Global.LogRetention, "log-retention", 10, "Number of run logs dirs (logs/<timestamp>-<mode>) to keep, 0 keeps all")
 	cmd.Flags().AddFlagSet(&flagSharedOpts)
 	cmd.Flags().AddFlagSet(&flagRetryOpts)
 	cmd.Flags().AddFlagSet(&flagDepTLS)
 	cmd.Flags().AddFlagSet(&flagSrcOpts)
 	cmd.Flags().AddFlagSet(&flagDestOpts)
+	cmd.AddCommand(NewReleaseCmd(log))
+	cmd.AddCommand(NewVerifyCmd(log))
 	return cmd
 }
 
-// Run - start the mirror functionality
+// Run - start the mirror functionality, the logs of the run and its report (run-report.json)
+// are written to logs/<timestamp>-<mode>, the logs of the previous runs are kept (see --log-retention)
 func (o *ExecutorSchema) Run(cmd *cobra.Command, args []string) error {
-
-	// clean up logs directory
-	os.RemoveAll(logsDir)
-
-	// create logs directory
-	err := os.MkdirAll(logsDir, 0755)
+	runDir, err := runlog.Create(logsDir, o.Opts.Mode, o.Opts.Global.LogRetention)
 	if err != nil {
 		o.Log.Error(" %v ", err)
-		return err
+		if len(runDir) == 0 {
+			return err
+		}
 	}
+	o.Opts.Global.LogsDir = runDir
+	o.Log.Info("logs directory %s ", runDir)
+
+	o.Report = report.New(o.Opts.Mode, o.Opts.Global.ConfigPath)
+	err = o.run(cmd, args)
+	if o.Batch != nil {
+		o.Report.Add(o.Batch.Results()...)
+	}
+	o.Report.Finish(err)
+	if werr := o.Report.Write(filepath.Join(runDir, report.ReportFile)); werr != nil {
+		o.Log.Error(" %v ", werr)
+	}
+	return err
+}
+
+// run - mirrors the images of the collectors
+func (o *ExecutorSchema) run(cmd *cobra.Command, args []string) error {
+	var err error
 
 	if o.Opts.Mode == mirrorToDisk {
 		// ensure working dir exists
@@ -194,44 +244,119 @@ func (o *ExecutorSchema) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// refuse to push a working dir that doesn't match its checksums (unless skipped)
+	if o.Opts.Mode == diskToMirror && !o.Opts.Global.SkipChecksums {
+		err = o.verifyChecksums()
+		if err != nil {
+			return err
+		}
+	}
+
 	var allRelatedImages []v1alpha3.CopyImageSchema
 
 	// do releases
 	imgs, err := o.Release.ReleaseImageCollector(cmd.Context())
 	if err != nil {
-		cleanUp()
 		return err
 	}
 	o.Log.Info("total release images to copy %d ", len(imgs))
 	o.Opts.ImageType = "release"
-	allRelatedImages = mergeImages(allRelatedImages, imgs)
+	allRelatedImages = mergeImages(allRelatedImages, withOrigin(imgs, imageref.TypeRelease))
 
 	// do operators
 	imgs, err = o.Operator.OperatorImageCollector(cmd.Context())
 	if err != nil {
-		cleanUp()
 		return err
 	}
 	o.Log.Info("total operator images to copy %d ", len(imgs))
 	o.Opts.ImageType = "operator"
-	allRelatedImages = mergeImages(allRelatedImages, imgs)
+	allRelatedImages = mergeImages(allRelatedImages, withOrigin(imgs, imageref.TypeOperator))
 
 	// do additionalImages
 	imgs, err = o.AdditionalImages.AdditionalImagesCollector(cmd.Context())
 	if err != nil {
-		cleanUp()
 		return err
 	}
 	o.Log.Info("total additional images to copy %d ", len(imgs))
-	allRelatedImages = mergeImages(allRelatedImages, imgs)
+	allRelatedImages = mergeImages(allRelatedImages, withOrigin(imgs, imageref.TypeAdditional))
+
+	// do helm charts
+	imgs, err = o.Helm.HelmImageCollector(cmd.Context())
+	if err != nil {
+		return err
+	}
+	o.Log.Info("total helm images to copy %d ", len(imgs))
+	allRelatedImages = mergeImages(allRelatedImages, withOrigin(imgs, imageref.TypeHelm))
+
+	// remove the blocked images (for all collectors)
+	allRelatedImages, err = filter.FilterBlockedImages(o.Log, o.Config.Mirror, allRelatedImages)
+	if err != nil {
+		return err
+	}
+
+	// the cached images are written to the blob store
+	if o.Opts.Mode == mirrorToDisk && len(o.Opts.Global.BlobStore) > 0 {
+		allRelatedImages, err = o.storeImages(allRelatedImages)
+		if err != nil {
+			return err
+		}
+	}
+
+	// estimate the disk space needed and check it fits (unless forced)
+	if o.Opts.Mode == mirrorToDisk && (o.Opts.Global.DryRun || !o.Opts.Global.Force) {
+		estimate := diskspace.EstimateImages(cmd.Context(), o.Log, o.Blobs, allRelatedImages, o.Opts)
+		estimate.Log(o.Log)
+		if !o.Opts.Global.DryRun && !o.Opts.Global.Force {
+			err = estimate.Check(o.Log, o.Opts.Global.Dir)
+			if err != nil {
+				o.removeCacheDirs(allRelatedImages)
+				return err
+			}
+		}
+	}
+
+	// skip the images already at the destination (unless forced)
+	if o.Opts.Mode == diskToMirror && !o.Opts.Global.Force {
+		var skipped int
+		all := allRelatedImages
+		allRelatedImages, skipped = batch.SkipPresentImages(cmd.Context(), o.Log, o.Digest, allRelatedImages, o.Opts)
+		o.Log.Info("total images skipped (already at destination) %d ", skipped)
+		o.reportSkipped(all, allRelatedImages)
+	}
+
+	if o.Opts.Global.DryRun {
+		for _, img := range allRelatedImages {
+			o.Log.Info("dry-run %s -> %s ", img.Source, img.Destination)
+		}
+		o.Log.Info("dry-run total images to copy %d ", len(allRelatedImages))
+		o.removeCacheDirs(allRelatedImages)
+		return nil
+	}
 
 	//call the batch worker
 	err = o.Batch.Worker(cmd.Context(), allRelatedImages, o.Opts)
 	if err != nil {
-		cleanUp()
 		return err
 	}
 
+	if o.Opts.Mode == mirrorToDisk && len(o.Opts.Global.BlobStore) > 0 {
+		err = o.gcBlobStore()
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.Opts.Mode == mirrorToDisk {
+		err = o.writeDiskToMirrorConfig()
+		if err != nil {
+			return err
+		}
+		err = o.writeChecksums()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -240,20 +365,40 @@ func (o *ExecutorSchema) Complete(args []string) {
 	// override log level
 	o.Log.Level(o.Opts.Global.LogLevel)
 	o.Log.Debug("imagesetconfig file %s ", o.Opts.Global.ConfigPath)
-	// read the ImageSetConfiguration
-	cfg, err := config.ReadConfig(o.Opts.Global.ConfigPath)
-	if err != nil {
-		o.Log.Error("imagesetconfig %v ", err)
+	// read the ImageSetConfiguration (optional with --from)
+	var cfg v1alpha2.ImageSetConfiguration
+	var err error
+	if len(o.Opts.Global.ConfigPath) > 0 || !o.discover() {
+		cfg, err = config.ReadConfig(o.Opts.Global.ConfigPath)
+		if err != nil {
+			o.Log.Error("imagesetconfig %v ", err)
+		}
 	}
 	o.Log.Trace("imagesetconfig : %v ", cfg)
 
+	// the content not set in the config is discovered from the working dir
+	if len(o.Opts.Global.From) > 0 {
+		for _, from := range []*string{&o.Opts.Global.ReleaseFrom, &o.Opts.Global.OperatorsFrom, &o.Opts.Global.AdditionalFrom} {
+			if len(*from) == 0 {
+				*from = o.Opts.Global.From
+			}
+		}
+	}
+
 	// update all dependant modules
 	mc := mirror.NewMirrorCopy()
 	md := mirror.NewMirrorDelete()
 	o.Manifest = manifest.New(o.Log)
 	o.Mirror = mirror.New(mc, md)
 	o.Config = cfg
-	o.Batch = batch.New(o.Log, o.Mirror, o.Manifest)
+	// the batch adds the images to the blob store (the collectors cache the release index and catalogs as is)
+	if len(o.Opts.Global.BlobStore) > 0 {
+		o.Batch = batch.New(clog.With(o.Log, "component", "batch"), blobstore.NewMirror(o.Log, o.Mirror), o.Manifest)
+	} else {
+		o.Batch = batch.New(clog.With(o.Log, "component", "batch"), o.Mirror, o.Manifest)
+	}
+	o.Digest = mirror.NewImageDigest()
+	o.Blobs = diskspace.NewImageBlobs()
 
 	// logic to check mode
 	var dest string
@@ -273,26 +418,33 @@ func (o *ExecutorSchema) Complete(args []string) {
 
 	signature := release.NewSignatureClient(o.Log, &o.Config, &o.Opts)
 	cn := release.NewCincinnati(o.Log, &o.Config, &o.Opts, client, false, signature)
-	o.Release = release.New(o.Log, o.Config, o.Opts, o.Mirror, o.Manifest, cn)
-	o.Operator = operator.New(o.Log, o.Config, o.Opts, o.Mirror, o.Manifest)
-	o.AdditionalImages = additional.New(o.Log, o.Config, o.Opts, o.Mirror, o.Manifest)
+	o.Release = release.New(collectorLog(o.Log, imageref.TypeRelease), o.Config, o.Opts, o.Mirror, o.Manifest, cn)
+	o.Operator = operator.New(collectorLog(o.Log, imageref.TypeOperator), o.Config, o.Opts, o.Mirror, o.Manifest)
+	o.AdditionalImages = additional.New(collectorLog(o.Log, imageref.TypeAdditional), o.Config, o.Opts, o.Mirror, o.Manifest)
+	o.Helm = helm.New(collectorLog(o.Log, imageref.TypeHelm), o.Config, o.Opts, o.Mirror, o.Manifest)
 
 }
 
 // Validate - cobra validation
 func (o *ExecutorSchema) Validate(dest []string) error {
-	if len(o.Opts.Global.ConfigPath) == 0 {
-		return fmt.Errorf("use the --config flag it is mandatory")
+	if o.discover() && !strings.Contains(dest[0], dockerProtocol) {
+		return fmt.Errorf("the --from flags are only used for diskToMirror (docker:// destination)")
+	}
+	if len(o.Opts.Global.ConfigPath) == 0 && !o.discover() {
+		return fmt.Errorf("use the --config flag it is mandatory (or --from for diskToMirror)")
 	}
 
-	if strings.Contains(dest[0], dockerProtocol) {
+	if strings.Contains(dest[0], dockerProtocol) && len(o.Opts.Global.ConfigPath) > 0 {
 		// read the ImageSetConfiguration
 		cfg, err := config.ReadConfig(o.Opts.Global.ConfigPath)
 		if err != nil {
 			return err
 		}
-		if len(cfg.Mirror.Platform.Release) == 0 {
-			return fmt.Errorf("ensure the release field is set and has dir:// prefix")
+		// when no release is set all releases in the working dir are mirrored
+		for _, x := range append([]string{cfg.Mirror.Platform.Release}, cfg.Mirror.Platform.ReleaseDirs...) {
+			if len(x) > 0 && !strings.Contains(x, dirProtocol) {
+				return fmt.Errorf("ensure the release and releaseDirs fields have a dir:// prefix")
+			}
 		}
 		for _, x := range cfg.Mirror.Operators {
 			if !strings.Contains(x.Catalog, dirProtocol) {
@@ -305,6 +457,9 @@ func (o *ExecutorSchema) Validate(dest []string) error {
 			}
 		}
 	}
+	if bs := o.Opts.Global.BlobStore; len(bs) > 0 && bs != blobstore.ModeGlobal && bs != blobstore.ModeType {
+		return fmt.Errorf("the --blob-store flag must be one of %s or %s", blobstore.ModeGlobal, blobstore.ModeType)
+	}
 	if strings.Contains(dest[0], ociProtocol) || strings.Contains(dest[0], dirProtocol) || strings.Contains(dest[0], dockerProtocol) {
 		return nil
 	} else {
@@ -312,6 +467,265 @@ func (o *ExecutorSchema) Validate(dest []string) error {
 	}
 }
 
+// storeImages - the images cached in the working dir (release components, operator related images,
+// additional and helm images) are written to the blob store instead, with their cache path as ref name
+// the cache dirs created by the collectors are removed and the images already in the store are skipped
+func (o *ExecutorSchema) storeImages(images []v1alpha3.CopyImageSchema) ([]v1alpha3.CopyImageSchema, error) {
+	stored, err := blobstore.Refs(o.Opts.Global.Dir, "")
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Clean(o.Opts.Global.Dir)
+	var result []v1alpha3.CopyImageSchema
+	for _, img := range images {
+		dir := cacheDir(img.Destination)
+		rel, err := filepath.Rel(root, dir)
+		if len(dir) == 0 || err != nil || !storedImage(filepath.ToSlash(rel)) {
+			result = append(result, img)
+			continue
+		}
+		ref := filepath.ToSlash(rel)
+		typeDir := filepath.Join(root, strings.Split(ref, "/")[0])
+		for strings.HasPrefix(dir, typeDir+"/") && os.Remove(dir) == nil {
+			dir = filepath.Dir(dir)
+		}
+		if stored[ref] {
+			o.Log.Info("image in blob store %s ", ref)
+			continue
+		}
+		img.Destination = blobstore.Destination(o.storeDir(ref), ref)
+		result = append(result, img)
+	}
+	return result, nil
+}
+
+// storedImage - true for the cache paths of the images written to the blob store
+// the release index (release-images/<name>/<version>) and catalog (operator-images/<catalog>/<tag>) layouts are kept
+func storedImage(ref string) bool {
+	hld := strings.Split(ref, "/")
+	switch hld[0] {
+	case releaseImageDir:
+		return len(hld) > 4 && hld[3] == "images"
+	case operatorImageDir:
+		return len(hld) > 4
+	case additionalImages, helmImageDir:
+		return len(hld) > 1
+	default:
+		return false
+	}
+}
+
+// storeDir - the blob store of an image, one store (global) or a store per image type
+func (o *ExecutorSchema) storeDir(ref string) string {
+	if o.Opts.Global.BlobStore == blobstore.ModeType {
+		return filepath.Join(o.Opts.Global.Dir, blobstore.StoreDir, strings.Split(ref, "/")[0])
+	}
+	return filepath.Join(o.Opts.Global.Dir, blobstore.StoreDir)
+}
+
+// gcBlobStore - removes the unreferenced blobs of the stores
+func (o *ExecutorSchema) gcBlobStore() error {
+	for _, dir := range []string{releaseImageDir, operatorImageDir, additionalImages, helmImageDir} {
+		storeDir := o.storeDir(dir)
+		if _, err := os.Stat(storeDir); err != nil {
+			continue
+		}
+		removed, err := blobstore.New(o.Log, storeDir).GC()
+		if err != nil {
+			return err
+		}
+		o.Log.Info("blob store %s (unreferenced blobs removed %d) ", storeDir, removed)
+		if o.Opts.Global.BlobStore == blobstore.ModeGlobal {
+			break
+		}
+	}
+	return nil
+}
+
+// discover - true if the images are discovered from a working dir (diskToMirror)
+func (o *ExecutorSchema) discover() bool {
+	g := o.Opts.Global
+	return len(g.From) > 0 || len(g.ReleaseFrom) > 0 || len(g.OperatorsFrom) > 0 || len(g.AdditionalFrom) > 0
+}
+
+// writeDiskToMirrorConfig - writes the ImageSetConfiguration that mirrors everything in the working dir
+// (releases, catalogs and additional images) with dir:// paths relative to the parent of the working dir
+// (working-dir/<destination>), the dir diskToMirror is run from
+func (o *ExecutorSchema) writeDiskToMirrorConfig() error {
+	abs, err := filepath.Abs(o.Opts.Global.Dir)
+	if err != nil {
+		return err
+	}
+	base := filepath.Dir(filepath.Dir(abs))
+	dirPath := func(path string) string {
+		if rel, err := filepath.Rel(base, path); err == nil {
+			return dirProtocol + filepath.ToSlash(rel)
+		}
+		return dirProtocol + path
+	}
+	cfg := v1alpha2.ImageSetConfiguration{}
+	cfg.SetGroupVersionKind(v1alpha2.GroupVersion.WithKind(v1alpha2.ImageSetConfigurationKind))
+	releases, err := release.DiskReleases(abs)
+	if err != nil {
+		return err
+	}
+	for _, r := range releases {
+		cfg.Mirror.Platform.ReleaseDirs = append(cfg.Mirror.Platform.ReleaseDirs, dirPath(r))
+	}
+	catalogs, err := operator.DiskCatalogs(abs)
+	if err != nil {
+		return err
+	}
+	for _, c := range catalogs {
+		cfg.Mirror.Operators = append(cfg.Mirror.Operators, v1alpha2.Operator{Catalog: dirPath(c)})
+	}
+	dirs, err := additional.DiskImageDirs(abs)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		cfg.Mirror.AdditionalImages = append(cfg.Mirror.AdditionalImages, v1alpha2.Image{Name: dirPath(d)})
+	}
+	// the helm images are always discovered, the diskToMirror settings are kept
+	cfg.Mirror.Helm.PublishCharts = o.Config.Mirror.Helm.PublishCharts
+	cfg.Mirror.DestinationRules = o.Config.Mirror.DestinationRules
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(o.Opts.Global.Dir, diskToMirrorConfig)
+	err = os.WriteFile(file, append([]byte("---\n"), data...), 0644)
+	if err != nil {
+		return err
+	}
+	o.Log.Info("diskToMirror imagesetconfig written to %s ", file)
+	return nil
+}
+
+// writeChecksums - writes the SHA256SUMS of the working dir and signs it when a sign key is set
+func (o *ExecutorSchema) writeChecksums() error {
+	count, err := checksum.Write(o.Opts.Global.Dir)
+	if err != nil {
+		return err
+	}
+	o.Log.Info("checksums of %d files written to %s ", count, filepath.Join(o.Opts.Global.Dir, checksum.SumsFile))
+	if len(o.Opts.Global.SignKey) == 0 {
+		return nil
+	}
+	var passphrase []byte
+	if len(o.Opts.Global.SignPassphraseFile) > 0 {
+		passphrase, err = os.ReadFile(o.Opts.Global.SignPassphraseFile)
+		if err != nil {
+			return err
+		}
+		passphrase = []byte(strings.TrimRight(string(passphrase), "\r\n"))
+	}
+	err = checksum.Sign(o.Opts.Global.Dir, o.Opts.Global.SignKey, passphrase)
+	if err != nil {
+		return err
+	}
+	o.Log.Info("checksums signed %s ", filepath.Join(o.Opts.Global.Dir, checksum.SignatureFile))
+	return nil
+}
+
+// verifyChecksums - verifies the SHA256SUMS (and signature) written by mirrorToDisk for the content that is
+// pushed (the --from dirs and the dir:// paths of the config), a missing SHA256SUMS is only an error when a
+// verify key is set
+func (o *ExecutorSchema) verifyChecksums() error {
+	contents := o.contentDirs()
+	if len(contents) == 0 {
+		if len(o.Opts.Global.VerifyKey) > 0 {
+			return fmt.Errorf("no --from dir or dir:// path to verify (use --skip-checksums to ignore)")
+		}
+		o.Log.Warn("no --from dir or dir:// path, the content is not verified ")
+		return nil
+	}
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, content := range contents {
+		found, err := checksum.Find(content)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			if len(o.Opts.Global.VerifyKey) > 0 {
+				return fmt.Errorf("no %s found for %s (use --skip-checksums to ignore)", checksum.SumsFile, content)
+			}
+			o.Log.Warn("no %s found for %s, the content is not verified ", checksum.SumsFile, content)
+		}
+		for _, dir := range found {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		count, err := checksum.Verify(dir, o.Opts.Global.VerifyKey)
+		if err != nil {
+			return fmt.Errorf("%v (use --skip-checksums to ignore)", err)
+		}
+		o.Log.Info("checksums of %d files verified in %s ", count, dir)
+	}
+	return nil
+}
+
+// contentDirs - the dirs the diskToMirror content is read from, the --from dirs and the dir:// paths of the config
+func (o *ExecutorSchema) contentDirs() []string {
+	g := o.Opts.Global
+	dirs := []string{g.From, g.ReleaseFrom, g.OperatorsFrom, g.AdditionalFrom}
+	refs := append([]string{o.Config.Mirror.Platform.Release}, o.Config.Mirror.Platform.ReleaseDirs...)
+	for _, op := range o.Config.Mirror.Operators {
+		refs = append(refs, op.Catalog)
+	}
+	for _, img := range o.Config.Mirror.AdditionalImages {
+		refs = append(refs, img.Name)
+	}
+	for _, ref := range refs {
+		if strings.HasPrefix(ref, dirProtocol) {
+			dirs = append(dirs, strings.TrimPrefix(ref, dirProtocol))
+		}
+	}
+	var result []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if len(dir) > 0 && !seen[dir] {
+			seen[dir] = true
+			result = append(result, dir)
+		}
+	}
+	return result
+}
+
+// removeCacheDirs - removes the (empty) cache dirs the collectors created for images that are not copied
+// otherwise the next run finds them and skips the images
+func (o *ExecutorSchema) removeCacheDirs(images []v1alpha3.CopyImageSchema) {
+	root := filepath.Clean(o.Opts.Global.Dir)
+	for _, img := range images {
+		dir := cacheDir(img.Destination)
+		for len(dir) > 0 && strings.HasPrefix(dir, root+"/") && os.Remove(dir) == nil {
+			dir = filepath.Dir(dir)
+		}
+	}
+}
+
+// cacheDir - the directory of an oci: (with an optional tag) or dir: destination
+func cacheDir(ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "oci:"):
+		dir := strings.TrimPrefix(strings.TrimPrefix(ref, "oci:"), "//")
+		if i := strings.LastIndex(dir, ":"); i > strings.LastIndex(dir, "/") {
+			dir = dir[:i]
+		}
+		return filepath.Clean(dir)
+	case strings.HasPrefix(ref, "dir:"):
+		return filepath.Clean(strings.TrimPrefix(strings.TrimPrefix(ref, "dir:"), "//"))
+	default:
+		return ""
+	}
+}
+
 // mergeImages - simple function to append related images
 // nolint
 func mergeImages(base, in []v1alpha3.CopyImageSchema) []v1alpha3.CopyImageSchema {
@@ -319,8 +733,28 @@ func mergeImages(base, in []v1alpha3.CopyImageSchema) []v1alpha3.CopyImageSchema
 	return base
 }
 
-// cleanUp - utility to clean directories
-func cleanUp() {
-	// clean up logs directory
-	os.RemoveAll(logsDir)
+// withOrigin - sets the collector that wanted the images
+func withOrigin(imgs []v1alpha3.CopyImageSchema, origin string) []v1alpha3.CopyImageSchema {
+	for i := range imgs {
+		imgs[i].Origin = origin
+	}
+	return imgs
+}
+
+// collectorLog - the logger of a collector (json lines have the collector field)
+func collectorLog(log clog.PluggableLoggerInterface, collector string) clog.PluggableLoggerInterface {
+	return clog.With(log, "component", "collector", "collector", collector)
+}
+
+// reportSkipped - adds the images that are not copied to the run report
+func (o *ExecutorSchema) reportSkipped(all, copied []v1alpha3.CopyImageSchema) {
+	kept := make(map[string]bool)
+	for _, img := range copied {
+		kept[img.Destination] = true
+	}
+	for _, img := range all {
+		if !kept[img.Destination] {
+			o.Report.Add(report.Image{Type: img.Origin, Source: img.Source, Destination: img.Destination, Status: report.StatusSkipped})
+		}
+	}
 }
@@ -2,15 +2,24 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/containers/image/v5/types"
+
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/checksum"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/config"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/diff"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/report"
 	"github.com/spf13/cobra"
 )
 
@@ -47,6 +56,12 @@ func TestExecutor(t *testing.T) {
 	}
 	log.Debug("imagesetconfig : %v", cfg)
 
+	// the mirrorToDisk runs write the diskToMirror config and the checksums of the working dir
+	defer os.Remove("tests/" + diskToMirrorConfig)
+	defer os.Remove("tests/" + checksum.SumsFile)
+	// and every run writes its logs and report to a logs dir
+	defer os.RemoveAll(logsDir)
+
 	// this test should cover over 80%
 
 	t.Run("Testing Executor : should pass", func(t *testing.T) {
@@ -60,6 +75,7 @@ func TestExecutor(t *testing.T) {
 			Operator:         collector,
 			Release:          collector,
 			AdditionalImages: collector,
+			Helm:             collector,
 			Batch:            batch,
 			Diff:             diff,
 		}
@@ -73,6 +89,146 @@ func TestExecutor(t *testing.T) {
 			log.Error(" %v ", err)
 			t.Fatalf("should not fail")
 		}
+		if _, err := os.Stat("tests/" + checksum.SumsFile); err != nil {
+			t.Fatalf("should write the checksums %v", err)
+		}
+		if _, err := config.ReadConfig("tests/" + diskToMirrorConfig); err != nil {
+			t.Fatalf("should write a valid diskToMirror config %v", err)
+		}
+		if !strings.HasPrefix(ex.Opts.Global.LogsDir, logsDir+"/") || !strings.HasSuffix(ex.Opts.Global.LogsDir, "-mirrorToDisk") {
+			t.Fatalf("should log to a run dir %s", ex.Opts.Global.LogsDir)
+		}
+		rep := readReport(t, ex.Opts.Global.LogsDir)
+		if rep.Status != report.StatusSuccess || rep.Totals["release"] == nil || rep.Totals["all"].Size != 10 {
+			t.Fatalf("should write the run report %v", rep)
+		}
+	})
+
+	t.Run("Testing Executor (checksums) : should fail", func(t *testing.T) {
+		collector := &Collector{Log: log, Config: cfg, Opts: opts, Fail: false}
+		batch := &Batch{Log: log, Config: cfg, Opts: opts}
+		ex := &ExecutorSchema{
+			Log:              log,
+			Config:           cfg,
+			Opts:             opts,
+			Operator:         collector,
+			Release:          collector,
+			AdditionalImages: collector,
+			Helm:             collector,
+			Batch:            batch,
+		}
+		dir := t.TempDir()
+		if err := os.MkdirAll(dir+"/mirror/release-images", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dir+"/mirror/image", []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := checksum.Write(dir + "/mirror"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dir+"/mirror/image", []byte("tampered"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// the working dir is not verified, the sums of the --release-from dir (in a parent) are
+		ex.Opts.Global.Dir = t.TempDir()
+		ex.Opts.Global.ReleaseFrom = dir + "/mirror/release-images"
+		defer func() { ex.Opts.Global.Dir, ex.Opts.Global.ReleaseFrom = "tests", "" }()
+		ex.Opts.Mode = diskToMirror
+		res := &cobra.Command{}
+		res.SetContext(context.Background())
+		err := ex.Run(res, []string{"docker://localhost:5000/test"})
+		if err == nil {
+			t.Fatalf("should fail")
+		}
+		ex.Opts.Global.SkipChecksums = true
+		defer func() { ex.Opts.Global.SkipChecksums = false }()
+		err = ex.Run(res, []string{"docker://localhost:5000/test"})
+		if err != nil {
+			t.Fatalf("should not fail (checksums skipped) %v", err)
+		}
+	})
+
+	t.Run("Testing Executor (checksums, release: dir:// config) : should fail", func(t *testing.T) {
+		collector := &Collector{Log: log, Config: cfg, Opts: opts, Fail: false}
+		batch := &Batch{Log: log, Config: cfg, Opts: opts}
+		ex := &ExecutorSchema{
+			Log:              log,
+			Config:           cfg,
+			Opts:             opts,
+			Operator:         collector,
+			Release:          collector,
+			AdditionalImages: collector,
+			Helm:             collector,
+			Batch:            batch,
+		}
+		dir := t.TempDir()
+		release := dir + "/mirror/release-images/ocp-release/4.14.1-x86_64"
+		if err := os.MkdirAll(release, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(release+"/index.json", []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := checksum.Write(dir + "/mirror"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(release+"/index.json", []byte(`{"tampered":true}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// the classic release config (no --from flags)
+		ex.Config.Mirror.Platform.Release = dirProtocol + release
+		ex.Opts.Global.Dir = t.TempDir()
+		defer func() { ex.Opts.Global.Dir = "tests" }()
+		ex.Opts.Mode = diskToMirror
+		res := &cobra.Command{}
+		res.SetContext(context.Background())
+		err := ex.Run(res, []string{"docker://localhost:5000/test"})
+		if err == nil || !strings.Contains(err.Error(), "index.json") {
+			t.Fatalf("should fail on the tampered release %v", err)
+		}
+	})
+
+	t.Run("Testing Executor (dry-run) : should pass", func(t *testing.T) {
+		collector := &Collector{Log: log, Config: cfg, Opts: opts, Fail: false}
+		batch := &Batch{Log: log, Config: cfg, Opts: opts, Fail: true}
+		ex := &ExecutorSchema{
+			Log:              log,
+			Config:           cfg,
+			Opts:             opts,
+			Operator:         collector,
+			Release:          collector,
+			AdditionalImages: collector,
+			Helm:             collector,
+			Batch:            batch,
+			Blobs:            &Blobs{},
+		}
+		ex.Opts.Global.DryRun = true
+		defer func() { ex.Opts.Global.DryRun = false }()
+
+		// the empty cache dir of an image created by a collector is removed
+		if err := os.MkdirAll("tests/additional-images/ns/app/v1", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile("tests/additional-images/keep", nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll("tests/additional-images")
+		ex.Opts.Mode = "mirrorToDisk"
+		res := &cobra.Command{}
+		res.SetContext(context.Background())
+		err := ex.Run(res, []string{"oci://test"})
+		if err != nil {
+			log.Error(" %v ", err)
+			t.Fatalf("should not fail (the batch worker is not called)")
+		}
+		ex.removeCacheDirs([]v1alpha3.CopyImageSchema{{Destination: "oci:tests/additional-images/ns/app/v1:v1"}})
+		if _, err := os.Stat("tests/additional-images/ns"); !os.IsNotExist(err) {
+			t.Fatalf("should remove the empty cache dirs")
+		}
+		if _, err := os.Stat("tests/additional-images"); err != nil {
+			t.Fatalf("should keep the non empty dirs")
+		}
 	})
 
 	t.Run("Testing Executor : should fail (batch worker)", func(t *testing.T) {
@@ -86,6 +242,7 @@ func TestExecutor(t *testing.T) {
 			Operator:         collector,
 			Release:          collector,
 			AdditionalImages: collector,
+			Helm:             collector,
 			Batch:            batch,
 			Diff:             diff,
 		}
@@ -98,6 +255,9 @@ func TestExecutor(t *testing.T) {
 		if err == nil {
 			t.Fatalf("should fail")
 		}
+		if rep := readReport(t, ex.Opts.Global.LogsDir); rep.Status != report.StatusFailed || len(rep.Error) == 0 {
+			t.Fatalf("should write the failed run report %v", rep)
+		}
 	})
 
 	t.Run("Testing Executor : should fail (release collector)", func(t *testing.T) {
@@ -112,6 +272,7 @@ func TestExecutor(t *testing.T) {
 			Operator:         operatorCollector,
 			Release:          releaseCollector,
 			AdditionalImages: releaseCollector,
+			Helm:             releaseCollector,
 			Batch:            batch,
 			Diff:             diff,
 		}
@@ -138,6 +299,7 @@ func TestExecutor(t *testing.T) {
 			Operator:         operatorCollector,
 			Release:          releaseCollector,
 			AdditionalImages: releaseCollector,
+			Helm:             releaseCollector,
 			Batch:            batch,
 			Diff:             diff,
 		}
@@ -152,6 +314,41 @@ func TestExecutor(t *testing.T) {
 		}
 	})
 
+	t.Run("Testing Executor : should fail (blocked release image)", func(t *testing.T) {
+		collector := &Collector{Log: log, Config: cfg, Opts: opts, Fail: false}
+		batch := &Batch{Log: log, Config: cfg, Opts: opts, Fail: false}
+		diff := &Diff{Log: log, Config: cfg, Opts: opts, Mirror: Mirror{}}
+		blockedCfg := cfg
+		blockedCfg.Mirror.BlockedImages = []v1alpha2.Image{{Name: "registry/name/namespace/sometestimage-a"}}
+		ex := &ExecutorSchema{
+			Log:              log,
+			Config:           blockedCfg,
+			Opts:             opts,
+			Operator:         collector,
+			Release:          collector,
+			AdditionalImages: collector,
+			Helm:             collector,
+			Batch:            batch,
+			Diff:             diff,
+		}
+
+		res := &cobra.Command{}
+		res.SilenceUsage = true
+		res.SetContext(context.Background())
+		ex.Opts.Mode = "mirrorToDisk"
+		err := ex.Run(res, []string{"oci://test"})
+		if err == nil {
+			t.Fatalf("should fail")
+		}
+
+		// allowed - the blocked images are removed from the batch
+		ex.Config.Mirror.AllowBlockedReleaseImages = true
+		err = ex.Run(res, []string{"oci://test"})
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+	})
+
 	t.Run("Testing Executor : should pass", func(t *testing.T) {
 		ex := &ExecutorSchema{
 			Log:    log,
@@ -181,6 +378,115 @@ func TestExecutor(t *testing.T) {
 			t.Fatalf("should fail")
 		}
 	})
+
+	t.Run("Testing Executor (from) : should pass", func(t *testing.T) {
+		ex := &ExecutorSchema{
+			Log:    log,
+			Config: cfg,
+			Opts:   opts,
+		}
+		configPath := ex.Opts.Global.ConfigPath
+		ex.Opts.Global.ConfigPath = ""
+		ex.Opts.Global.From = "working-dir"
+		defer func() { ex.Opts.Global.ConfigPath, ex.Opts.Global.From = configPath, "" }()
+		err := ex.Validate([]string{"docker://localhost:5000/test"})
+		if err != nil {
+			t.Fatalf("should not fail (the config is optional) %v", err)
+		}
+		err = ex.Validate([]string{"oci://test"})
+		if err == nil {
+			t.Fatalf("should fail (only used for diskToMirror)")
+		}
+	})
+
+	t.Run("Testing Executor (blob store) : should fail", func(t *testing.T) {
+		ex := &ExecutorSchema{
+			Log:    log,
+			Config: cfg,
+			Opts:   opts,
+		}
+		ex.Opts.Global.BlobStore = "images"
+		defer func() { ex.Opts.Global.BlobStore = "" }()
+		err := ex.Validate([]string{"oci://test"})
+		if err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+}
+
+func TestWriteDiskToMirrorConfig(t *testing.T) {
+	log := clog.New("trace")
+	root := t.TempDir()
+	for _, d := range []string{"working-dir/mirror/release-images/ocp-release/4.14.1-x86_64", "working-dir/mirror/additional-images/ubi8/ubi/latest"} {
+		if err := os.MkdirAll(root+"/"+d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: root + "/working-dir/mirror"}, Mode: mirrorToDisk}
+	ex := &ExecutorSchema{Log: log, Opts: opts}
+
+	t.Run("Testing writeDiskToMirrorConfig : should pass", func(t *testing.T) {
+		if err := ex.writeDiskToMirrorConfig(); err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		cfg, err := config.ReadConfig(root + "/working-dir/mirror/" + diskToMirrorConfig)
+		if err != nil {
+			t.Fatalf("should write a valid config %v", err)
+		}
+		// the paths are relative to the dir diskToMirror is run from (the parent of working-dir)
+		if len(cfg.Mirror.Platform.ReleaseDirs) != 1 || cfg.Mirror.Platform.ReleaseDirs[0] != "dir://working-dir/mirror/release-images/ocp-release/4.14.1-x86_64" {
+			t.Fatalf("should list the release relative to the working dir parent %v", cfg.Mirror.Platform.ReleaseDirs)
+		}
+		if len(cfg.Mirror.AdditionalImages) != 1 || cfg.Mirror.AdditionalImages[0].Name != "dir://working-dir/mirror/additional-images" {
+			t.Fatalf("should list the additional images relative to the working dir parent %v", cfg.Mirror.AdditionalImages)
+		}
+	})
+}
+
+func TestStoreImages(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir() + "/working-dir/mirror"
+	component := dir + "/release-images/ocp-release/4.14.1-x86_64/images/etcd"
+	additional := dir + "/additional-images/ubi8/ubi/latest"
+	for _, d := range []string{component, additional} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: dir, BlobStore: blobstore.ModeType}, Mode: mirrorToDisk}
+	ex := &ExecutorSchema{Log: log, Opts: opts}
+
+	t.Run("Testing storeImages : should pass", func(t *testing.T) {
+		images := []v1alpha3.CopyImageSchema{
+			{Source: "docker://quay.io/openshift-release-dev/ocp-release:4.14.1-x86_64", Destination: "oci:" + dir + "/release-images/ocp-release/4.14.1-x86_64"},
+			{Source: "docker://quay.io/openshift-release-dev/etcd@sha256:abc", Destination: "dir:" + component},
+			{Source: "docker://registry.access.redhat.com/ubi8/ubi:latest", Destination: "oci:" + additional},
+		}
+		res, err := ex.storeImages(images)
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 3 {
+			t.Fatalf("should keep all the images %v", res)
+		}
+		// the release index is kept in its own layout
+		if res[0].Destination != images[0].Destination {
+			t.Fatalf("should not store the release index %s", res[0].Destination)
+		}
+		if res[1].Destination != "oci:"+dir+"/blob-store/release-images:release-images/ocp-release/4.14.1-x86_64/images/etcd" {
+			t.Fatalf("should store the component in the release images store %s", res[1].Destination)
+		}
+		if res[2].Destination != "oci:"+dir+"/blob-store/additional-images:additional-images/ubi8/ubi/latest" {
+			t.Fatalf("should store the additional image in the additional images store %s", res[2].Destination)
+		}
+		// the empty cache dirs are removed, the image type dirs are kept
+		if _, err := os.Stat(component); !os.IsNotExist(err) {
+			t.Fatalf("should remove the cache dir %s", component)
+		}
+		if _, err := os.Stat(dir + "/additional-images"); err != nil {
+			t.Fatalf("should keep the image type dir %v", err)
+		}
+	})
 }
 
 // setup mocks
@@ -188,7 +494,7 @@ func TestExecutor(t *testing.T) {
 type Mirror struct{}
 
 // for this test scenario we only need to mock
-// ReleaseImageCollector, OperatorImageCollector and Batchr
+// ReleaseImageCollector, OperatorImageCollector, HelmImageCollector and Batchr
 type Collector struct {
 	Log    clog.PluggableLoggerInterface
 	Config v1alpha2.ImageSetConfiguration
@@ -227,6 +533,26 @@ func (o *Diff) WriteMetadata(dir, dest string, sch diff.SequenceSchema, cfg v1al
 	return nil
 }
 
+// readReport - reads the run report of the last run
+func readReport(t *testing.T, dir string) *report.Report {
+	data, err := os.ReadFile(filepath.Join(dir, report.ReportFile))
+	if err != nil {
+		t.Fatalf("should write the run report %v", err)
+	}
+	rep := &report.Report{}
+	if err := json.Unmarshal(data, rep); err != nil {
+		t.Fatalf("should write a valid run report %v", err)
+	}
+	return rep
+}
+
+// Blobs - mocks the size estimate (a 10 byte layer)
+type Blobs struct{}
+
+func (o *Blobs) Blobs(ctx context.Context, img v1alpha3.CopyImageSchema, opts *mirror.CopyOptions) ([]types.BlobInfo, error) {
+	return []types.BlobInfo{{Digest: "sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea", Size: 10}}, nil
+}
+
 func (o *Batch) Worker(ctx context.Context, images []v1alpha3.CopyImageSchema, opts mirror.CopyOptions) error {
 	if o.Fail {
 		return fmt.Errorf("forced error")
@@ -234,6 +560,10 @@ func (o *Batch) Worker(ctx context.Context, images []v1alpha3.CopyImageSchema, o
 	return nil
 }
 
+func (o *Batch) Results() []report.Image {
+	return []report.Image{{Type: "release", Source: "docker://registry/a:v1", Destination: "dir://working-dir/a", Size: 10, Status: report.StatusSuccess}}
+}
+
 func (o *Collector) OperatorImageCollector(ctx context.Context) ([]v1alpha3.CopyImageSchema, error) {
 	if o.Fail {
 		return []v1alpha3.CopyImageSchema{}, fmt.Errorf("forced error operator collector")
@@ -278,3 +608,13 @@ func (o *Collector) AdditionalImagesCollector(ctx context.Context) ([]v1alpha3.C
 	}
 	return test, nil
 }
+
+func (o *Collector) HelmImageCollector(ctx context.Context) ([]v1alpha3.CopyImageSchema, error) {
+	if o.Fail {
+		return []v1alpha3.CopyImageSchema{}, fmt.Errorf("forced error helm collector")
+	}
+	test := []v1alpha3.CopyImageSchema{
+		{Source: "docker://registry/name/namespace/sometestimage-h@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea", Destination: "oci:test"},
+	}
+	return test, nil
+}
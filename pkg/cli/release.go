@@ -0,0 +1,272 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/uuid"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/release"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	planExamples = templates.Examples(
+		`
+		# Show the upgrade path between two releases
+		mirror release plan --from 4.12.3 --to 4.14.10 --arch amd64
+
+		# Generate a Graphviz DOT file and an ImageSetConfiguration for the path
+		mirror release plan --from 4.12.3 --to 4.14.10 --format dot --output plan.dot --isc isc-plan.yaml
+		`,
+	)
+	toolsExamples = templates.Examples(
+		`
+		# Extract the linux oc and openshift-install binaries for all mirrored releases
+		mirror release tools --dir working-dir
+
+		# Extract the binaries (including the mac and windows clients) for a single release
+		mirror release tools --dir working-dir --release ocp-release/4.12.0-x86_64 --all-os
+		`,
+	)
+	diffExamples = templates.Examples(
+		`
+		# Show the components that changed between two extracted releases
+		mirror release diff 4.12.3 4.12.7
+
+		# Use name/version when several architectures are extracted
+		mirror release diff ocp-release/4.12.3-x86_64 ocp-release/4.12.7-x86_64 --format json
+		`,
+	)
+)
+
+// PlanOptions - options for the release plan sub command
+type PlanOptions struct {
+	From          string
+	To            string
+	Arch          string
+	ChannelPrefix string
+	Format        string
+	Output        string
+	ISC           string
+}
+
+// ToolsOptions - options for the release tools sub command
+type ToolsOptions struct {
+	Dir     string
+	Release string
+	AllOS   bool
+}
+
+// DiffOptions - options for the release diff sub command
+type DiffOptions struct {
+	Log    clog.PluggableLoggerInterface
+	Dir    string
+	Format string
+}
+
+// NewReleaseCmd - cobra entry point for the release sub commands
+func NewReleaseCmd(log clog.PluggableLoggerInterface) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release",
+		Short: "Release payload utilities",
+	}
+	cmd.AddCommand(newReleasePlanCmd(log))
+	cmd.AddCommand(newReleaseToolsCmd(log))
+	cmd.AddCommand(newReleaseDiffCmd(log))
+	return cmd
+}
+
+// newReleasePlanCmd - calculates the upgrade path between two releases
+func newReleasePlanCmd(log clog.PluggableLoggerInterface) *cobra.Command {
+	opts := &PlanOptions{}
+	cmd := &cobra.Command{
+		Use:     "plan",
+		Short:   "Show the upgrade hops, channels and release images between two versions",
+		Example: planExamples,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := opts.Run(cmd, log)
+			if err != nil {
+				log.Error("%v ", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.From, "from", "", "Version to upgrade from (mandatory)")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Version to upgrade to (mandatory)")
+	cmd.Flags().StringVar(&opts.Arch, "arch", "amd64", "Architecture of the release payloads")
+	cmd.Flags().StringVar(&opts.ChannelPrefix, "channel-prefix", "stable", "Channel prefix used to look up the upgrade graph")
+	cmd.Flags().StringVar(&opts.Format, "format", release.PlanFormatText, "Output format one of (text, json, dot)")
+	cmd.Flags().StringVar(&opts.Output, "output", "", "Write the plan to a file (default stdout)")
+	cmd.Flags().StringVar(&opts.ISC, "isc", "", "Write an ImageSetConfiguration that mirrors the upgrade path to a file")
+	return cmd
+}
+
+// Run - calculate and output the upgrade plan
+func (o *PlanOptions) Run(cmd *cobra.Command, log clog.PluggableLoggerInterface) error {
+	if len(o.From) == 0 || len(o.To) == 0 {
+		return fmt.Errorf("use the --from and --to flags they are mandatory")
+	}
+	from, err := semver.Parse(o.From)
+	if err != nil {
+		return fmt.Errorf("invalid --from version %s: %v", o.From, err)
+	}
+	to, err := semver.Parse(o.To)
+	if err != nil {
+		return fmt.Errorf("invalid --to version %s: %v", o.To, err)
+	}
+
+	client, err := release.NewOCPClient(uuid.New())
+	if err != nil {
+		return err
+	}
+	plan, err := release.PlanUpgrade(cmd.Context(), client, o.Arch, o.ChannelPrefix, from, to)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = cmd.OutOrStdout()
+	if len(o.Output) > 0 {
+		f, err := os.Create(o.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	err = release.WritePlan(w, plan, o.Format)
+	if err != nil {
+		return err
+	}
+
+	if len(o.ISC) > 0 {
+		f, err := os.Create(o.ISC)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		err = release.WritePlanImageSetConfig(f, plan)
+		if err != nil {
+			return err
+		}
+		log.Info("imagesetconfig written to %s ", o.ISC)
+	}
+	return nil
+}
+
+// newReleaseToolsCmd - extracts the oc and openshift-install binaries from mirrored releases
+func newReleaseToolsCmd(log clog.PluggableLoggerInterface) *cobra.Command {
+	opts := &ToolsOptions{}
+	cmd := &cobra.Command{
+		Use:     "tools",
+		Short:   "Extract the oc and openshift-install binaries from mirrored release payloads",
+		Example: toolsExamples,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := opts.Run(log)
+			if err != nil {
+				log.Error("%v ", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.Dir, "dir", "working-dir", "Assets directory")
+	cmd.Flags().StringVar(&opts.Release, "release", "", "Release (name/version) to extract the binaries from (default all mirrored releases)")
+	cmd.Flags().BoolVar(&opts.AllOS, "all-os", false, "Also extract the clients for other operating systems (as tarballs)")
+	return cmd
+}
+
+// Run - extract the binaries to dir/tools/<version>
+func (o *ToolsOptions) Run(log clog.PluggableLoggerInterface) error {
+	releases := []string{o.Release}
+	if len(o.Release) == 0 {
+		var err error
+		releases, err = mirroredReleases(o.Dir)
+		if err != nil {
+			return err
+		}
+	}
+	if len(releases) == 0 {
+		return fmt.Errorf("no mirrored releases found in %s", o.Dir+"/"+releaseImageDir)
+	}
+	for _, r := range releases {
+		_, err := release.ExtractTools(log, manifest.New(log), o.Dir, r, o.AllOS)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mirroredReleases - returns the name/version directories in dir/release-images
+func mirroredReleases(dir string) ([]string, error) {
+	var releases []string
+	names, err := os.ReadDir(dir + "/" + releaseImageDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !name.IsDir() {
+			continue
+		}
+		versions, err := os.ReadDir(strings.Join([]string{dir, releaseImageDir, name.Name()}, "/"))
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			if version.IsDir() {
+				releases = append(releases, name.Name()+"/"+version.Name())
+			}
+		}
+	}
+	return releases, nil
+}
+
+// newReleaseDiffCmd - compares the components of two extracted releases
+func newReleaseDiffCmd(log clog.PluggableLoggerInterface) *cobra.Command {
+	opts := &DiffOptions{Log: log}
+	cmd := &cobra.Command{
+		Use:     "diff <from> <to>",
+		Short:   "Show the components (image-references) added, removed or changed between two releases",
+		Example: diffExamples,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := opts.Run(cmd, args)
+			if err != nil {
+				log.Error("%v ", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.Dir, "dir", "working-dir", "Assets directory")
+	cmd.Flags().StringVar(&opts.Format, "format", release.PlanFormatText, "Output format one of (text, json)")
+	return cmd
+}
+
+// Run - compare the image-references of the two releases
+func (o *DiffOptions) Run(cmd *cobra.Command, args []string) error {
+	var names []string
+	var components [][]v1alpha3.RelatedImage
+	for _, r := range args {
+		dir, err := release.FindReleaseDir(o.Dir, r)
+		if err != nil {
+			return err
+		}
+		images, err := release.ReleaseComponents(manifest.New(o.Log), dir)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.Base(dir))
+		components = append(components, images)
+	}
+	diff := release.DiffReleases(names[0], components[0], names[1], components[1])
+	return release.WriteDiff(cmd.OutOrStdout(), diff, o.Format)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/config"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/operator"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/release"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/verify"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	verifyExamples = templates.Examples(
+		`
+		# Verify the images (blobs and manifests) and the release components in the working dir
+		mirror verify --dir working-dir
+
+		# Also verify the catalog related images selected in the imageset configuration
+		mirror verify --dir working-dir --config mirror-config.yaml
+		`,
+	)
+)
+
+// VerifyOptions - options for the verify sub command
+type VerifyOptions struct {
+	Dir        string
+	ConfigPath string
+}
+
+// NewVerifyCmd - cobra entry point for the verify sub command
+func NewVerifyCmd(log clog.PluggableLoggerInterface) *cobra.Command {
+	opts := &VerifyOptions{}
+	cmd := &cobra.Command{
+		Use:     "verify",
+		Short:   "Verify the integrity and completeness of the images in a working dir",
+		Example: verifyExamples,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// the --config flag is inherited from the mirror command
+			opts.ConfigPath, _ = cmd.Flags().GetString("config")
+			err := opts.Run(log)
+			if err != nil {
+				log.Error("%v ", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.Dir, "dir", "working-dir", "Assets directory")
+	return cmd
+}
+
+// Run - verify the blobs and manifests of every image and the release (and catalog) images are present
+func (o *VerifyOptions) Run(log clog.PluggableLoggerInterface) error {
+	if _, err := os.Stat(o.Dir); err != nil {
+		return fmt.Errorf("working dir %v", err)
+	}
+	report, err := verify.Images(log, o.Dir)
+	if err != nil {
+		return err
+	}
+
+	var cfg v1alpha2.ImageSetConfiguration
+	if len(o.ConfigPath) > 0 {
+		cfg, err = config.ReadConfig(o.ConfigPath)
+		if err != nil {
+			return err
+		}
+	}
+	m := manifest.New(log)
+	missing, err := release.MissingComponents(m, o.Dir, cfg.Mirror.Platform.Components)
+	if err != nil {
+		return err
+	}
+	for _, path := range missing {
+		report.Add(path, "missing release component")
+	}
+	missing, err = operator.MissingImages(m, o.Dir, cfg.Mirror.Operators)
+	if err != nil {
+		return err
+	}
+	for _, path := range missing {
+		report.Add(path, "missing catalog related image")
+	}
+
+	for _, p := range report.Problems {
+		log.Error("%s : %s", p.Path, p.Message)
+	}
+	log.Info("verified images %d blobs %d ", report.Images, report.Blobs)
+	if len(report.Problems) > 0 {
+		return fmt.Errorf("verification of %s failed with %d problems", o.Dir, len(report.Problems))
+	}
+	log.Info("verification of %s completed successfully", o.Dir)
+	return nil
+}
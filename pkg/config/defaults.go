@@ -11,7 +11,7 @@ func Complete(cfg *v1alpha2.ImageSetConfiguration) {
 }
 
 func completeReleaseArchitectures(cfg *v1alpha2.ImageSetConfiguration) {
-	if len(cfg.Mirror.Platform.Channels) != 0 && len(cfg.Mirror.Platform.Architectures) == 0 {
+	if (len(cfg.Mirror.Platform.Channels) != 0 || len(cfg.Mirror.Platform.Releases) != 0) && len(cfg.Mirror.Platform.Architectures) == 0 {
 		cfg.Mirror.Platform.Architectures = []string{v1alpha2.DefaultPlatformArchitecture}
 	}
 }
@@ -2,15 +2,21 @@ package config
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/filter"
 )
 
 type validationFunc func(cfg *v1alpha2.ImageSetConfiguration) error
 
-var validationChecks = []validationFunc{validateOperatorOptions, validateReleaseChannels}
+var validationChecks = []validationFunc{validateOperatorOptions, validateReleaseChannels, validateEUSChannels, validateReleases, validateComponentFilter, validateBlockedImages, validateAdditionalImages, validateDestinationRules}
 
 // Validate will check an ImagesetConfiguration for input errors.
 func Validate(cfg *v1alpha2.ImageSetConfiguration) error {
@@ -52,3 +58,96 @@ func validateReleaseChannels(cfg *v1alpha2.ImageSetConfiguration) error {
 	}
 	return nil
 }
+
+func validateEUSChannels(cfg *v1alpha2.ImageSetConfiguration) error {
+	for _, channel := range cfg.Mirror.Platform.Channels {
+		if !channel.EUS {
+			continue
+		}
+		if len(channel.MinVersion) == 0 || len(channel.MaxVersion) == 0 {
+			return fmt.Errorf(
+				"release channel %q: eus upgrades require minVersion and maxVersion", channel.Name,
+			)
+		}
+		min, err := semver.Parse(channel.MinVersion)
+		if err != nil {
+			return fmt.Errorf("release channel %q: %v", channel.Name, err)
+		}
+		max, err := semver.Parse(channel.MaxVersion)
+		if err != nil {
+			return fmt.Errorf("release channel %q: %v", channel.Name, err)
+		}
+		if min.Minor%2 != 0 || max.Minor%2 != 0 {
+			return fmt.Errorf(
+				"release channel %q: eus upgrades require even minor versions", channel.Name,
+			)
+		}
+	}
+	return nil
+}
+
+func validateReleases(cfg *v1alpha2.ImageSetConfiguration) error {
+	for _, release := range cfg.Mirror.Platform.Releases {
+		if (len(release.Version) == 0) == (len(release.Image) == 0) {
+			return fmt.Errorf("release: exactly one of version or image must be set")
+		}
+		if len(release.Version) > 0 {
+			if _, err := semver.Parse(release.Version); err != nil {
+				return fmt.Errorf("release %q: %v", release.Version, err)
+			}
+		}
+		if len(release.Image) > 0 && !strings.Contains(release.Image, "@sha256:") {
+			return fmt.Errorf("release %q: image must be digest pinned", release.Image)
+		}
+	}
+	return nil
+}
+
+func validateComponentFilter(cfg *v1alpha2.ImageSetConfiguration) error {
+	filter := cfg.Mirror.Platform.Components
+	for _, pattern := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("release component pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateBlockedImages(cfg *v1alpha2.ImageSetConfiguration) error {
+	_, err := filter.NewBlockedImages(cfg.Mirror.BlockedImages)
+	return err
+}
+
+func validateAdditionalImages(cfg *v1alpha2.ImageSetConfiguration) error {
+	for _, img := range cfg.Mirror.AdditionalImages {
+		if img.Tags == nil {
+			continue
+		}
+		name := strings.TrimPrefix(img.Name, "docker://")
+		if strings.Contains(name, "@") || strings.LastIndex(name, ":") > strings.LastIndex(name, "/") {
+			return fmt.Errorf("additional image %q: tags can only be set for a repository (no tag or digest)", img.Name)
+		}
+		if _, err := regexp.Compile(img.Tags.Regex); err != nil {
+			return fmt.Errorf("additional image %q: tags regex %v", img.Name, err)
+		}
+		if len(img.Tags.SemverRange) > 0 {
+			if _, err := semver.ParseRange(img.Tags.SemverRange); err != nil {
+				return fmt.Errorf("additional image %q: tags semverRange %v", img.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateDestinationRules(cfg *v1alpha2.ImageSetConfiguration) error {
+	rules := cfg.Mirror.DestinationRules
+	if rules.FlattenDepth < 0 {
+		return fmt.Errorf("destination rules: flattenDepth must not be negative")
+	}
+	for _, r := range rules.PrefixRewrites {
+		if len(strings.Trim(r.From, "/")) == 0 {
+			return fmt.Errorf("destination rules: prefix rewrite from must be set")
+		}
+	}
+	return nil
+}
@@ -131,6 +131,209 @@ func TestValidate(t *testing.T) {
 			},
 			expError: "invalid configuration: release channel \"channel\": duplicate found in configuration",
 		},
+		{
+			name: "Valid/EUSChannel",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Channels: []v1alpha2.ReleaseChannel{
+								{
+									Name:       "eus-4.14",
+									MinVersion: "4.12.3",
+									MaxVersion: "4.14.10",
+									EUS:        true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/EUSChannelOddMinor",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Channels: []v1alpha2.ReleaseChannel{
+								{
+									Name:       "eus-4.14",
+									MinVersion: "4.13.3",
+									MaxVersion: "4.14.10",
+									EUS:        true,
+								},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: release channel \"eus-4.14\": eus upgrades require even minor versions",
+		},
+		{
+			name: "Invalid/EUSChannelNoRange",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Channels: []v1alpha2.ReleaseChannel{
+								{
+									Name: "eus-4.14",
+									EUS:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: release channel \"eus-4.14\": eus upgrades require minVersion and maxVersion",
+		},
+		{
+			name: "Valid/Releases",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Releases: []v1alpha2.Release{
+								{Version: "4.12.3"},
+								{Image: "quay.io/openshift-release-dev/ocp-release@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/ReleaseVersionAndImage",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Releases: []v1alpha2.Release{
+								{Version: "4.12.3", Image: "quay.io/openshift-release-dev/ocp-release:4.12.3-x86_64"},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: release: exactly one of version or image must be set",
+		},
+		{
+			name: "Invalid/ReleaseImageNotPinned",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Releases: []v1alpha2.Release{
+								{Image: "quay.io/openshift-release-dev/ocp-release:4.12.3-x86_64"},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: release \"quay.io/openshift-release-dev/ocp-release:4.12.3-x86_64\": image must be digest pinned",
+		},
+		{
+			name: "Valid/ComponentFilter",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Components: v1alpha2.ComponentFilter{
+								Exclude: []string{"*alibaba*", "*nutanix*"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/ComponentFilterPattern",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						Platform: v1alpha2.Platform{
+							Components: v1alpha2.ComponentFilter{
+								Include: []string{"[azure"},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: release component pattern \"[azure\": syntax error in pattern",
+		},
+		{
+			name: "Invalid/BlockedImagesRegex",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						BlockedImages: []v1alpha2.Image{{Name: "regex:ubi(8"}},
+					},
+				},
+			},
+			expError: "invalid configuration: blocked image \"regex:ubi(8\": error parsing regexp: missing closing ): `^(?:ubi(8)$`",
+		},
+		{
+			name: "Valid/AdditionalImagesTags",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						AdditionalImages: []v1alpha2.Image{
+							{Name: "registry.local:5000/team/app", Tags: &v1alpha2.TagSelection{Regex: `^v1\.`, SemverRange: ">=1.4.0 <2.0.0", Latest: 3}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Valid/DestinationRules",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						DestinationRules: v1alpha2.DestinationRules{
+							PrefixRewrites: []v1alpha2.PrefixRewrite{{From: "openshift-release-dev", To: "ocp"}},
+							FlattenDepth:   2,
+							Roots:          v1alpha2.DestinationRoots{Operators: "operators"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/DestinationRulesFlattenDepth",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						DestinationRules: v1alpha2.DestinationRules{FlattenDepth: -1},
+					},
+				},
+			},
+			expError: "invalid configuration: destination rules: flattenDepth must not be negative",
+		},
+		{
+			name: "Invalid/DestinationRulesPrefixRewrite",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						DestinationRules: v1alpha2.DestinationRules{PrefixRewrites: []v1alpha2.PrefixRewrite{{From: "/", To: "ocp"}}},
+					},
+				},
+			},
+			expError: "invalid configuration: destination rules: prefix rewrite from must be set",
+		},
+		{
+			name: "Invalid/AdditionalImagesTagsReference",
+			config: &v1alpha2.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+					Mirror: v1alpha2.Mirror{
+						AdditionalImages: []v1alpha2.Image{
+							{Name: "registry.local/team/app:v1", Tags: &v1alpha2.TagSelection{Latest: 3}},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: additional image \"registry.local/team/app:v1\": tags can only be set for a repository (no tag or digest)",
+		},
 	}
 
 	for _, c := range cases {
@@ -6,13 +6,12 @@ import (
 	"os"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
-	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/config"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
 )
@@ -46,8 +45,9 @@ type DiffCollector struct {
 }
 
 type DiffSchema struct {
-	from  string
-	found bool
+	from      string
+	found     bool
+	imageType string
 }
 
 func (o *DiffCollector) DeleteImages(ctx context.Context) error {
@@ -68,7 +68,7 @@ func (o *DiffCollector) DeleteImages(ctx context.Context) error {
 			return err
 		}
 
-		imgsToDelete, err := batchWorkerConverter(o.Opts.Destination, imgs)
+		imgsToDelete, err := batchWorkerConverter(o.Opts.Destination, o.Config.Mirror.DestinationRules, imgs)
 		if err != nil {
 			return err
 		}
@@ -170,39 +170,39 @@ func calculateDiff(prevConfig, cfg v1alpha2.ImageSetConfiguration) (map[string]D
 	// runs should be deleted
 
 	for _, pr := range prevConfig.Mirror.Platform.Channels {
-		images[pr.Name] = DiffSchema{from: "previous", found: false}
+		images[pr.Name] = DiffSchema{from: "previous", found: false, imageType: imageref.TypeRelease}
 		for _, r := range cfg.Mirror.Platform.Channels {
-			images[r.Name] = DiffSchema{from: "current", found: false}
+			images[r.Name] = DiffSchema{from: "current", found: false, imageType: imageref.TypeRelease}
 			if reflect.DeepEqual(r, pr) {
-				images[pr.Name] = DiffSchema{from: "both", found: true}
+				images[pr.Name] = DiffSchema{from: "both", found: true, imageType: imageref.TypeRelease}
 			}
 		}
 	}
 	for _, prOp := range prevConfig.Mirror.Operators {
-		images[prOp.Catalog] = DiffSchema{from: "previous", found: false}
+		images[prOp.Catalog] = DiffSchema{from: "previous", found: false, imageType: imageref.TypeOperator}
 		for _, op := range cfg.Mirror.Operators {
-			images[op.Catalog] = DiffSchema{from: "current", found: false}
+			images[op.Catalog] = DiffSchema{from: "current", found: false, imageType: imageref.TypeOperator}
 			for _, prevPkg := range prOp.Packages {
-				images[prevPkg.Name] = DiffSchema{from: "previous", found: false}
+				images[prevPkg.Name] = DiffSchema{from: "previous", found: false, imageType: imageref.TypeOperator}
 				for _, pkg := range op.Packages {
-					images[pkg.Name] = DiffSchema{from: "current", found: false}
+					images[pkg.Name] = DiffSchema{from: "current", found: false, imageType: imageref.TypeOperator}
 					if reflect.DeepEqual(pkg, prevPkg) {
-						images[prevPkg.Name] = DiffSchema{from: "both", found: true}
+						images[prevPkg.Name] = DiffSchema{from: "both", found: true, imageType: imageref.TypeOperator}
 					}
 				}
 			}
 			if reflect.DeepEqual(op, prOp) {
-				images[prOp.Catalog] = DiffSchema{from: "both", found: true}
+				images[prOp.Catalog] = DiffSchema{from: "both", found: true, imageType: imageref.TypeOperator}
 			}
 		}
 	}
 
 	for _, prAi := range prevConfig.Mirror.AdditionalImages {
-		images[prAi.Name] = DiffSchema{from: "previous", found: false}
+		images[prAi.Name] = DiffSchema{from: "previous", found: false, imageType: imageref.TypeAdditional}
 		for _, ai := range cfg.Mirror.AdditionalImages {
-			images[ai.Name] = DiffSchema{from: "current", found: false}
+			images[ai.Name] = DiffSchema{from: "current", found: false, imageType: imageref.TypeAdditional}
 			if reflect.DeepEqual(ai, prAi) {
-				images[prAi.Name] = DiffSchema{from: "both", found: true}
+				images[prAi.Name] = DiffSchema{from: "both", found: true, imageType: imageref.TypeAdditional}
 			}
 		}
 	}
@@ -211,15 +211,16 @@ func calculateDiff(prevConfig, cfg v1alpha2.ImageSetConfiguration) (map[string]D
 
 // batchWorkerConverter - needs work , have to consider oci format
 // and release image channels
-func batchWorkerConverter(destination string, imgs map[string]DiffSchema) ([]string, error) {
+// the destination rules are applied as for the pushed (diskToMirror) images
+func batchWorkerConverter(destination string, rules v1alpha2.DestinationRules, imgs map[string]DiffSchema) ([]string, error) {
 	var result []string
 	for k, v := range imgs {
 		if !v.found && v.from == "previous" {
-			i, err := customImageParser(k)
+			i, err := imageref.Parse(k)
 			if err != nil {
 				return result, err
 			}
-			src := destination + i.Namespace + "/" + i.Component
+			src := destination + "/" + imageref.DestinationPath(rules, v.imageType, imageref.FromDir(imageref.Dir(i)))
 			result = append(result, src)
 		}
 	}
@@ -249,20 +250,3 @@ func readMetaData(dir string) (SequenceSchema, error) {
 	}
 	return schema, nil
 }
-
-// customImageParser
-func customImageParser(image string) (*v1alpha3.ImageRefSchema, error) {
-	var irs *v1alpha3.ImageRefSchema
-	var component string
-	parts := strings.Split(image, "/")
-	if len(parts) < 3 {
-		return irs, fmt.Errorf("[customImageParser] image url seems to be wrong %s ", image)
-	}
-	if strings.Contains(parts[2], "@") {
-		component = strings.Split(parts[2], "@")[0]
-	} else {
-		component = parts[2]
-	}
-	irs = &v1alpha3.ImageRefSchema{Repository: parts[0], Namespace: parts[1], Component: component}
-	return irs, nil
-}
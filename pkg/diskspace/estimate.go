@@ -0,0 +1,221 @@
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	"github.com/docker/go-units"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	dockerProtocol string = "docker://"
+	concurrency    int    = 8
+	errMsg         string = "[DiskSpace] %v "
+)
+
+// ErrUnsupported - the free space of a filesystem can't be read on this platform
+var ErrUnsupported = errors.New("free space check not supported on this platform")
+
+// BlobsInterface - resolves the blobs of an image to copy (from its manifests only)
+type BlobsInterface interface {
+	Blobs(ctx context.Context, img v1alpha3.CopyImageSchema, opts *mirror.CopyOptions) ([]types.BlobInfo, error)
+}
+
+type ImageBlobs struct{}
+
+// NewImageBlobs - returns a new ImageBlobs instance
+func NewImageBlobs() BlobsInterface {
+	return &ImageBlobs{}
+}
+
+// Estimate - the (deduplicated) size of the blobs to copy
+type Estimate struct {
+	Total  int64
+	Types  map[string]int64
+	Images map[string]int
+	Failed int
+}
+
+// EstimateImages - fetches the manifests of the images (docker sources) and sums the size of
+// the unique blobs (by digest) per image type (origin) and in total, at most 8 images at a time
+// images that can't be resolved are counted as failed and not included in the sizes
+func EstimateImages(ctx context.Context, log clog.PluggableLoggerInterface, blobs BlobsInterface, images []v1alpha3.CopyImageSchema, opts mirror.CopyOptions) *Estimate {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	est := &Estimate{Types: make(map[string]int64), Images: make(map[string]int)}
+	seen := make(map[digest.Digest]bool)
+	seenType := make(map[string]map[digest.Digest]bool)
+
+	for _, img := range images {
+		if !strings.HasPrefix(img.Source, dockerProtocol) {
+			continue
+		}
+		wg.Add(1)
+		go func(img v1alpha3.CopyImageSchema) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			infos, err := blobs.Blobs(ctx, img, &opts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Warn("size estimate %s : %v", img.Source, err)
+				est.Failed++
+				return
+			}
+			if seenType[img.Origin] == nil {
+				seenType[img.Origin] = make(map[digest.Digest]bool)
+			}
+			est.Images[img.Origin]++
+			for _, info := range infos {
+				// schema1 manifests have no config and no layer sizes
+				if len(info.Digest) == 0 || info.Size < 0 {
+					continue
+				}
+				if !seenType[img.Origin][info.Digest] {
+					seenType[img.Origin][info.Digest] = true
+					est.Types[img.Origin] += info.Size
+				}
+				if !seen[info.Digest] {
+					seen[info.Digest] = true
+					est.Total += info.Size
+				}
+			}
+		}(img)
+	}
+	wg.Wait()
+	return est
+}
+
+// Log - logs the estimate per image type and the total
+func (o *Estimate) Log(log clog.PluggableLoggerInterface) {
+	var names []string
+	for name := range o.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Info("estimated size %s %s (%d images) ", name, units.BytesSize(float64(o.Types[name])), o.Images[name])
+	}
+	if o.Failed > 0 {
+		log.Warn("size estimate failed for %d images (not included)", o.Failed)
+	}
+	log.Info("estimated size total %s ", units.BytesSize(float64(o.Total)))
+}
+
+// Check - returns an error if the estimate doesn't fit in the free space of the filesystem of dir
+// only warns when the free space can't be read on this platform
+func (o *Estimate) Check(log clog.PluggableLoggerInterface, dir string) error {
+	free, err := FreeSpace(dir)
+	if errors.Is(err, ErrUnsupported) {
+		log.Warn("disk space check skipped : %v", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	if o.Total > free {
+		return fmt.Errorf(errMsg, fmt.Sprintf("not enough disk space in %s : required %s available %s (use --force to ignore)",
+			dir, units.BytesSize(float64(o.Total)), units.BytesSize(float64(free))))
+	}
+	return nil
+}
+
+func (o *ImageBlobs) Blobs(ctx context.Context, img v1alpha3.CopyImageSchema, opts *mirror.CopyOptions) ([]types.BlobInfo, error) {
+	ref, err := alltransports.ParseImageName(img.Source)
+	if err != nil {
+		return nil, err
+	}
+	sysCtx, err := opts.SrcImage.NewSystemContext()
+	if err != nil {
+		return nil, err
+	}
+	src, err := ref.NewImageSource(ctx, sysCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	mb, mt, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	infos := []types.BlobInfo{{Digest: digest.FromBytes(mb), Size: int64(len(mb))}}
+	if !manifest.MIMETypeIsMultiImage(mt) {
+		blobs, err := manifestBlobs(mb, mt)
+		return append(infos, blobs...), err
+	}
+	instances, err := copiedInstances(mb, mt, img, opts, sysCtx)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		d := instance
+		imb, imt, err := src.GetManifest(ctx, &d)
+		if err != nil {
+			return nil, err
+		}
+		blobs, err := manifestBlobs(imb, imt)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, types.BlobInfo{Digest: d, Size: int64(len(imb))})
+		infos = append(infos, blobs...)
+	}
+	return infos, nil
+}
+
+// copiedInstances - the instances of a manifest list that are copied, the image level
+// architectures and multi-arch settings override the global settings (see batch)
+func copiedInstances(mb []byte, mt string, img v1alpha3.CopyImageSchema, opts *mirror.CopyOptions, sysCtx *types.SystemContext) ([]digest.Digest, error) {
+	if len(img.Architectures) > 0 {
+		return mirror.SelectInstances(mb, mt, img.Architectures)
+	}
+	list, err := manifest.ListFromBlob(mb, mt)
+	if err != nil {
+		return nil, err
+	}
+	multiArch := opts.MultiArch
+	if opts.All {
+		multiArch = "all"
+	}
+	if len(img.MultiArch) > 0 {
+		multiArch = img.MultiArch
+	}
+	switch multiArch {
+	case "all":
+		return list.Instances(), nil
+	case "index-only":
+		return nil, nil
+	default:
+		d, err := list.ChooseInstance(sysCtx)
+		if err != nil {
+			return nil, err
+		}
+		return []digest.Digest{d}, nil
+	}
+}
+
+// manifestBlobs - the config and layers of a manifest
+func manifestBlobs(mb []byte, mt string) ([]types.BlobInfo, error) {
+	m, err := manifest.FromBlob(mb, mt)
+	if err != nil {
+		return nil, err
+	}
+	infos := []types.BlobInfo{m.ConfigInfo()}
+	for _, layer := range m.LayerInfos() {
+		infos = append(infos, layer.BlobInfo)
+	}
+	return infos, nil
+}
@@ -0,0 +1,106 @@
+package diskspace
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateImages(t *testing.T) {
+	log := clog.New("trace")
+	base := types.BlobInfo{Digest: digest.FromString("base"), Size: 100}
+	blobs := &Blobs{blobs: map[string][]types.BlobInfo{
+		"docker://registry/a:v1": {base, {Digest: digest.FromString("a"), Size: 10}},
+		"docker://registry/b:v1": {base, {Digest: digest.FromString("b"), Size: 20}},
+		"docker://registry/c:v1": {base, {Digest: digest.FromString("c"), Size: 30}, {Digest: digest.FromString("schema1"), Size: -1}},
+	}}
+	images := []v1alpha3.CopyImageSchema{
+		{Source: "docker://registry/a:v1", Origin: "release"},
+		{Source: "docker://registry/b:v1", Origin: "release"},
+		{Source: "docker://registry/c:v1", Origin: "operator"},
+		{Source: "docker://registry/missing:v1", Origin: "operator"},
+		{Source: "oci:/cache/d", Origin: "additional"},
+	}
+
+	t.Run("Testing EstimateImages : should pass", func(t *testing.T) {
+		est := EstimateImages(context.Background(), log, blobs, images, mirror.CopyOptions{})
+		require.Equal(t, int64(160), est.Total)
+		require.Equal(t, map[string]int64{"release": 130, "operator": 130}, est.Types)
+		require.Equal(t, map[string]int{"release": 2, "operator": 1}, est.Images)
+		require.Equal(t, 1, est.Failed)
+		est.Log(log)
+	})
+
+	t.Run("Testing Check : should pass", func(t *testing.T) {
+		require.NoError(t, (&Estimate{Total: 1}).Check(log, t.TempDir()))
+	})
+
+	t.Run("Testing Check : should fail", func(t *testing.T) {
+		err := (&Estimate{Total: math.MaxInt64}).Check(log, t.TempDir())
+		require.ErrorContains(t, err, "not enough disk space")
+		_, err = FreeSpace("/does/not/exist")
+		require.Error(t, err)
+	})
+}
+
+func TestCopiedInstances(t *testing.T) {
+	amd64 := digest.FromString("amd64")
+	arm64 := digest.FromString("arm64")
+	list := manifest.OCI1IndexFromComponents(nil, nil)
+	list.Manifests = append(list.Manifests,
+		ociInstance(amd64, "amd64"),
+		ociInstance(arm64, "arm64"),
+	)
+	mb, err := list.Serialize()
+	require.NoError(t, err)
+	sysCtx := &types.SystemContext{ArchitectureChoice: "arm64", OSChoice: "linux"}
+
+	type spec struct {
+		name     string
+		img      v1alpha3.CopyImageSchema
+		opts     mirror.CopyOptions
+		expected []digest.Digest
+	}
+	cases := []spec{
+		{name: "system", expected: []digest.Digest{arm64}},
+		{name: "all", opts: mirror.CopyOptions{All: true}, expected: []digest.Digest{amd64, arm64}},
+		{name: "image multi-arch", img: v1alpha3.CopyImageSchema{MultiArch: "index-only"}, opts: mirror.CopyOptions{All: true}},
+		{name: "architectures", img: v1alpha3.CopyImageSchema{Architectures: []string{"amd64"}}, opts: mirror.CopyOptions{All: true}, expected: []digest.Digest{amd64}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := copiedInstances(mb, imgspecv1.MediaTypeImageIndex, c.img, &c.opts, sysCtx)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, res)
+		})
+	}
+
+	_, err = copiedInstances(mb, imgspecv1.MediaTypeImageIndex, v1alpha3.CopyImageSchema{Architectures: []string{"s390x"}}, &mirror.CopyOptions{}, sysCtx)
+	require.Error(t, err)
+}
+
+// Blobs - mocks the image blobs lookup
+type Blobs struct {
+	blobs map[string][]types.BlobInfo
+}
+
+func (o *Blobs) Blobs(ctx context.Context, img v1alpha3.CopyImageSchema, opts *mirror.CopyOptions) ([]types.BlobInfo, error) {
+	if infos, ok := o.blobs[img.Source]; ok {
+		return infos, nil
+	}
+	return nil, fmt.Errorf("manifest unknown %s", img.Source)
+}
+
+func ociInstance(d digest.Digest, arch string) imgspecv1.Descriptor {
+	return imgspecv1.Descriptor{MediaType: imgspecv1.MediaTypeImageManifest, Digest: d, Size: 1, Platform: &imgspecv1.Platform{Architecture: arch, OS: "linux"}}
+}
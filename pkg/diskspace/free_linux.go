@@ -0,0 +1,14 @@
+package diskspace
+
+import (
+	"syscall"
+)
+
+// FreeSpace - the space available (to an unprivileged user) on the filesystem of dir
+func FreeSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * stat.Bsize, nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package diskspace
+
+// FreeSpace - the space available on the filesystem of dir (linux only)
+func FreeSpace(dir string) (int64, error) {
+	return 0, ErrUnsupported
+}
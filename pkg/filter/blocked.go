@@ -0,0 +1,158 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+)
+
+const (
+	dockerProtocol string = "docker://"
+	regexPrefix    string = "regex:"
+	globChars      string = "*?["
+	errMsg         string = "[BlockedImages] %v "
+)
+
+// BlockedImages - matches image references against the mirror.blockedImages entries
+//
+// An entry is either
+//   - an exact reference (with a tag or digest) i.e quay.io/ns/name:v1
+//   - a repository name (all tags and digests) i.e quay.io/ns/name
+//   - a glob on the repository name i.e quay.io/ns/*
+//   - a regex on the repository name prefixed with regex: i.e regex:.*/ubi[0-9]/.*
+type BlockedImages struct {
+	exact   map[string]bool
+	repos   map[string]bool
+	globs   []string
+	regexes []*regexp.Regexp
+}
+
+// NewBlockedImages - parses the blocked images entries
+func NewBlockedImages(images []v1alpha2.Image) (*BlockedImages, error) {
+	b := &BlockedImages{exact: make(map[string]bool), repos: make(map[string]bool)}
+	for _, img := range images {
+		name := strings.TrimPrefix(img.Name, dockerProtocol)
+		switch {
+		case len(name) == 0:
+			return nil, fmt.Errorf("blocked image name is empty")
+		case strings.HasPrefix(name, regexPrefix):
+			re, err := regexp.Compile("^(?:" + strings.TrimPrefix(name, regexPrefix) + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("blocked image %q: %v", img.Name, err)
+			}
+			b.regexes = append(b.regexes, re)
+		case strings.ContainsAny(name, globChars):
+			if _, err := path.Match(name, ""); err != nil {
+				return nil, fmt.Errorf("blocked image %q: %v", img.Name, err)
+			}
+			b.globs = append(b.globs, name)
+		case repository(name) == name:
+			b.repos[name] = true
+		default:
+			b.exact[name] = true
+		}
+	}
+	return b, nil
+}
+
+// Match - returns true if the image (docker reference) is blocked
+func (o *BlockedImages) Match(image string) bool {
+	ref := strings.TrimPrefix(image, dockerProtocol)
+	if o.exact[ref] {
+		return true
+	}
+	repo := repository(ref)
+	if o.repos[repo] {
+		return true
+	}
+	for _, g := range o.globs {
+		if ok, _ := path.Match(g, repo); ok {
+			return true
+		}
+	}
+	for _, re := range o.regexes {
+		if re.MatchString(repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterBlockedImages - removes the blocked images from the (merged) images to copy
+// only docker references are checked, that is the source in mirrorToDisk and the destination in diskToMirror
+func FilterBlockedImages(log clog.PluggableLoggerInterface, cfg v1alpha2.Mirror, images []v1alpha3.CopyImageSchema) ([]v1alpha3.CopyImageSchema, error) {
+	if len(cfg.BlockedImages) == 0 {
+		return images, nil
+	}
+	blocked, err := NewBlockedImages(cfg.BlockedImages)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	var result []v1alpha3.CopyImageSchema
+	var release []string
+	for _, img := range images {
+		if !blocked.isBlocked(img) {
+			result = append(result, img)
+			continue
+		}
+		log.Warn("blocked image %s (wanted by %s)", blockedRef(img), img.Origin)
+		if img.Origin == imageref.TypeRelease {
+			release = append(release, blockedRef(img))
+		}
+		// the collectors create the (empty) cache dir before the copy
+		if dir := localDir(img.Destination); len(dir) > 0 {
+			os.Remove(dir)
+		}
+	}
+	if len(release) > 0 && !cfg.AllowBlockedReleaseImages {
+		return nil, fmt.Errorf(errMsg, fmt.Sprintf("images required by the release payload are blocked %v (set allowBlockedReleaseImages to ignore)", release))
+	}
+	log.Info("total blocked images %d ", len(images)-len(result))
+	return result, nil
+}
+
+// isBlocked - checks the docker references of a copy
+func (o *BlockedImages) isBlocked(img v1alpha3.CopyImageSchema) bool {
+	for _, ref := range []string{img.Source, img.Destination} {
+		if strings.HasPrefix(ref, dockerProtocol) && o.Match(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedRef - the docker reference used in the log messages
+func blockedRef(img v1alpha3.CopyImageSchema) string {
+	if strings.HasPrefix(img.Source, dockerProtocol) {
+		return strings.TrimPrefix(img.Source, dockerProtocol)
+	}
+	return strings.TrimPrefix(img.Destination, dockerProtocol)
+}
+
+// localDir - the directory of an oci: or dir: destination
+func localDir(ref string) string {
+	for _, transport := range []string{"oci:", "dir:"} {
+		if strings.HasPrefix(ref, transport) {
+			return strings.TrimPrefix(strings.TrimPrefix(ref, transport), "//")
+		}
+	}
+	return ""
+}
+
+// repository - strips the tag or digest from a reference
+func repository(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
@@ -0,0 +1,67 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockedImagesMatch(t *testing.T) {
+	blocked, err := NewBlockedImages([]v1alpha2.Image{
+		{Name: "quay.io/ns/exact:v1"},
+		{Name: "docker://quay.io/ns/repo"},
+		{Name: "registry.io/glob/*"},
+		{Name: "regex:.*/ubi[0-9]/.*"},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]bool{
+		"docker://quay.io/ns/exact:v1":                  true,
+		"quay.io/ns/exact:v2":                           false,
+		"quay.io/ns/repo@sha256:abc":                    true,
+		"quay.io/ns/repo:latest":                        true,
+		"quay.io/ns/repository:latest":                  false,
+		"registry.io/glob/name:1.0":                     true,
+		"registry.io/glob/nested/name:1.0":              false,
+		"registry.access.redhat.com/ubi8/ubi-minimal:8": true,
+		"localhost:5000/ubi8:8":                         false,
+	}
+	for image, expected := range tests {
+		require.Equal(t, expected, blocked.Match(image), image)
+	}
+
+	_, err = NewBlockedImages([]v1alpha2.Image{{Name: "regex:[a-"}})
+	require.Error(t, err)
+	_, err = NewBlockedImages([]v1alpha2.Image{{Name: "quay.io/[ns"}})
+	require.Error(t, err)
+}
+
+func TestFilterBlockedImages(t *testing.T) {
+	log := clog.New("trace")
+	images := []v1alpha3.CopyImageSchema{
+		{Source: "docker://quay.io/ocp/art-dev@sha256:aaa", Destination: "oci:" + t.TempDir(), Origin: "release"},
+		{Source: "docker://quay.io/ns/blocked:v1", Destination: "oci:" + t.TempDir(), Origin: "additional"},
+		{Source: "oci:/tmp/working-dir/additional-images/ns/other", Destination: "docker://localhost:5000/ns/other:v1", Origin: "additional"},
+	}
+
+	t.Run("Testing FilterBlockedImages : should pass", func(t *testing.T) {
+		cfg := v1alpha2.Mirror{BlockedImages: []v1alpha2.Image{{Name: "quay.io/ns/blocked"}, {Name: "*/ns/other"}}}
+		res, err := FilterBlockedImages(log, cfg, images)
+		require.NoError(t, err)
+		require.Equal(t, images[:1], res)
+	})
+
+	t.Run("Testing FilterBlockedImages release : should fail", func(t *testing.T) {
+		cfg := v1alpha2.Mirror{BlockedImages: []v1alpha2.Image{{Name: "quay.io/ocp/*"}}}
+		_, err := FilterBlockedImages(log, cfg, images)
+		require.Error(t, err)
+
+		cfg.AllowBlockedReleaseImages = true
+		res, err := FilterBlockedImages(log, cfg, images)
+		require.NoError(t, err)
+		require.Equal(t, images[1:], res)
+	})
+}
@@ -0,0 +1,420 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/types"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+)
+
+const (
+	indexJson          string = "index.json"
+	dockerProtocol     string = "docker://"
+	ociProtocolTrimmed string = "oci:"
+	chartsDir          string = "charts"
+	localChartsDir     string = "local"
+	helmImagesDir      string = "helm-images"
+	chartsRepo         string = "charts"
+	diskToMirror       string = "diskToMirror"
+	mirrorToDisk       string = "mirrorToDisk"
+	errMsg             string = "[HelmImageCollector] %v "
+	chartsTimeout             = 5 * time.Minute
+)
+
+// defaultImagePaths - the JSON paths used to find images in the rendered templates
+var defaultImagePaths = []string{
+	"{.spec.template.spec.initContainers[*].image}",
+	"{.spec.template.spec.containers[*].image}",
+	"{.spec.jobTemplate.spec.template.spec.initContainers[*].image}",
+	"{.spec.jobTemplate.spec.template.spec.containers[*].image}",
+	"{.spec.initContainers[*].image}",
+	"{.spec.containers[*].image}",
+}
+
+type CollectorInterface interface {
+	HelmImageCollector(ctx context.Context) ([]v1alpha3.CopyImageSchema, error)
+}
+
+func New(log clog.PluggableLoggerInterface,
+	config v1alpha2.ImageSetConfiguration,
+	opts mirror.CopyOptions,
+	mirror mirror.MirrorInterface,
+	manifest manifest.ManifestInterface,
+) CollectorInterface {
+	return &Collector{Log: log, Config: config, Opts: opts, Mirror: mirror, Manifest: manifest}
+}
+
+type Collector struct {
+	Log      clog.PluggableLoggerInterface
+	Mirror   mirror.MirrorInterface
+	Manifest manifest.ManifestInterface
+	Config   v1alpha2.ImageSetConfiguration
+	Opts     mirror.CopyOptions
+}
+
+// HelmImageCollector - this looks into the helm field
+// taking into account the mode we are in (mirrorToDisk, diskToMirror)
+// in mirrorToDisk the charts are downloaded (or copied) to working-dir/charts
+// and rendered with the default values to find the images
+func (o *Collector) HelmImageCollector(ctx context.Context) ([]v1alpha3.CopyImageSchema, error) {
+
+	var allImages []v1alpha3.CopyImageSchema
+
+	if o.Opts.Mode == mirrorToDisk {
+		charts, err := o.getCharts()
+		if err != nil {
+			return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+		}
+		// the helm images dir anchors the charts dir of the working dir (diskToMirror)
+		if len(charts) > 0 {
+			if err := os.MkdirAll(strings.Join([]string{o.Opts.Global.Dir, helmImagesDir}, "/"), 0755); err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+		}
+		seen := make(map[string]bool)
+		for path, cfg := range charts {
+			images, err := findChartImages(path, cfg.ImagePaths)
+			if err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+			o.Log.Debug("chart %s images %v", path, images)
+			for _, img := range images {
+				if seen[img] {
+					continue
+				}
+				seen[img] = true
+				irs, err := imageref.Parse(img)
+				if err != nil {
+					return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+				}
+				cacheDir := strings.Join([]string{o.Opts.Global.Dir, helmImagesDir, imageref.Dir(irs)}, "/")
+				if _, err := os.Stat(cacheDir); errors.Is(err, os.ErrNotExist) {
+					err := os.MkdirAll(cacheDir, 0755)
+					if err != nil {
+						return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+					}
+					src := dockerProtocol + img
+					dest := ociProtocolTrimmed + cacheDir
+					o.Log.Debug("source %s", src)
+					o.Log.Debug("destination %s", dest)
+					allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest})
+				} else {
+					o.Log.Info("cache dir exists %s", cacheDir)
+				}
+			}
+		}
+	}
+
+	if o.Opts.Mode == diskToMirror {
+		regex, err := regexp.Compile(indexJson)
+		if err != nil {
+			return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+		}
+		// the charts and images are discovered in the --from working dir
+		root := o.Opts.Global.From
+		if len(root) == 0 {
+			root = o.Opts.Global.Dir
+		}
+		err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// the charts dir is next to the helm images dir (working-dir/charts)
+			if d.IsDir() && d.Name() == helmImagesDir && o.Config.Mirror.Helm.PublishCharts {
+				charts := filepath.Join(filepath.Dir(path), chartsDir)
+				if _, err := os.Stat(charts); err == nil {
+					if err := o.publishCharts(charts); err != nil {
+						return err
+					}
+				}
+			}
+			// the images written to the blob store (mirrorToDisk --blob-store) of the working dir
+			if d.IsDir() && d.Name() == blobstore.StoreDir {
+				images, err := blobstore.Images(filepath.Dir(path), helmImagesDir)
+				if err != nil {
+					return err
+				}
+				for _, img := range images {
+					dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeHelm, imageref.FromDir(strings.TrimPrefix(img.Ref, helmImagesDir)))
+					allImages = append(allImages, v1alpha3.CopyImageSchema{Source: img.Source, Destination: dest, PreserveDigests: strings.Contains(dest, "@")})
+				}
+				return filepath.SkipDir
+			}
+			if d.IsDir() || !regex.MatchString(d.Name()) || !strings.Contains(path, "/"+helmImagesDir+"/") {
+				return nil
+			}
+			hld := strings.Split(filepath.Dir(path), "/"+helmImagesDir+"/")
+			src := ociProtocolTrimmed + filepath.Dir(path)
+			dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeHelm, imageref.FromDir(hld[1]))
+			allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest, PreserveDigests: strings.Contains(dest, "@")})
+			return filepath.SkipDir
+		})
+		if err != nil {
+			return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+		}
+	}
+	return allImages, nil
+}
+
+// getCharts - downloads the repository charts and copies the local charts
+// to working-dir/charts, returns the chart archives with their config
+func (o *Collector) getCharts() (map[string]v1alpha2.Chart, error) {
+	charts := make(map[string]v1alpha2.Chart)
+	var client *chartClient
+	if len(o.Config.Mirror.Helm.Repositories) > 0 {
+		sys, err := o.Opts.SrcImage.NewSystemContext()
+		if err != nil {
+			return nil, err
+		}
+		client, err = newChartClient(sys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range o.Config.Mirror.Helm.Repositories {
+		destDir := strings.Join([]string{o.Opts.Global.Dir, chartsDir, r.Name}, "/")
+		if err := os.MkdirAll(destDir, 0755); err != nil {
+			return nil, err
+		}
+		for _, c := range r.Charts {
+			path, err := downloadChart(client, r.URL, c, destDir)
+			if err != nil {
+				return nil, err
+			}
+			o.Log.Info("chart %s downloaded to %s", c.Name, path)
+			charts[path] = c
+		}
+	}
+	destDir := strings.Join([]string{o.Opts.Global.Dir, chartsDir, localChartsDir}, "/")
+	if len(o.Config.Mirror.Helm.Local) > 0 {
+		if err := os.MkdirAll(destDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range o.Config.Mirror.Helm.Local {
+		ch, err := loader.Load(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		path, err := chartutil.Save(ch, destDir)
+		if err != nil {
+			return nil, err
+		}
+		o.Log.Info("chart %s copied to %s", c.Name, path)
+		charts[path] = c
+	}
+	return charts, nil
+}
+
+// repoIndex - the fields used from a helm repository index.yaml
+type repoIndex struct {
+	Entries map[string][]struct {
+		Version string   `json:"version"`
+		URLs    []string `json:"urls"`
+	} `json:"entries"`
+}
+
+// downloadChart - downloads a chart (version) from a repository, an existing archive is reused
+// if no version is set the latest (semver) version in the repository index is used
+func downloadChart(client *chartClient, repoURL string, c v1alpha2.Chart, destDir string) (string, error) {
+	if len(c.Version) > 0 {
+		path := fmt.Sprintf("%s/%s-%s.tgz", destDir, c.Name, c.Version)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	base, err := url.Parse(strings.TrimSuffix(repoURL, "/") + "/")
+	if err != nil {
+		return "", err
+	}
+	data, err := client.get(base.String() + "index.yaml")
+	if err != nil {
+		return "", err
+	}
+	var index repoIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return "", err
+	}
+	var version string
+	var chartURL string
+	var latest semver.Version
+	for _, entry := range index.Entries[c.Name] {
+		if len(entry.URLs) == 0 {
+			continue
+		}
+		if len(c.Version) > 0 {
+			if entry.Version == c.Version {
+				version, chartURL = entry.Version, entry.URLs[0]
+				break
+			}
+			continue
+		}
+		v, err := semver.ParseTolerant(entry.Version)
+		if err == nil && (len(version) == 0 || v.GT(latest)) {
+			latest = v
+			version, chartURL = entry.Version, entry.URLs[0]
+		}
+	}
+	if len(chartURL) == 0 {
+		return "", fmt.Errorf("chart %s version %q not found in repository %s", c.Name, c.Version, repoURL)
+	}
+	ref, err := base.Parse(chartURL)
+	if err != nil {
+		return "", err
+	}
+	data, err = client.get(ref.String())
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("%s/%s-%s.tgz", destDir, c.Name, version)
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// chartClient - downloads the repository index and charts with the tls settings of the source
+// (--src-tls-verify and --src-cert-dir) and the credentials of the repository host in the auth file
+type chartClient struct {
+	client *http.Client
+	sys    *types.SystemContext
+}
+
+// newChartClient - returns a client with a timeout (a stalled repository fails the run)
+func newChartClient(sys *types.SystemContext) (*chartClient, error) {
+	tlsConfig, err := newTLSConfig(sys)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// the registry credentials (--src-creds) are not sent to the chart repositories
+	auth := *sys
+	auth.DockerAuthConfig = nil
+	return &chartClient{client: &http.Client{Transport: transport, Timeout: chartsTimeout}, sys: &auth}, nil
+}
+
+// get - downloads a file
+func (o *chartClient) get(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := config.GetCredentials(o.sys, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	if len(creds.Username) > 0 {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s : %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// findChartImages - renders the chart templates with the default values
+// and returns the (sorted) images found with the default and custom image paths
+func findChartImages(path string, imagePaths []string) ([]string, error) {
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := renderChart(ch)
+	if err != nil {
+		return nil, err
+	}
+	paths := append(append([]string{}, defaultImagePaths...), imagePaths...)
+	found := make(map[string]bool)
+	for _, m := range releaseutil.SplitManifests(manifests) {
+		var obj interface{}
+		if err := yaml.Unmarshal([]byte(m), &obj); err != nil || obj == nil {
+			continue
+		}
+		for _, p := range paths {
+			jp := jsonpath.New("image").AllowMissingKeys(true)
+			if err := jp.Parse(p); err != nil {
+				return nil, fmt.Errorf("image path %s: %v", p, err)
+			}
+			results, err := jp.FindResults(obj)
+			if err != nil {
+				continue
+			}
+			for _, r := range results {
+				for _, v := range r {
+					if img, ok := v.Interface().(string); ok && len(img) > 0 {
+						found[img] = true
+					}
+				}
+			}
+		}
+	}
+	var images []string
+	for img := range found {
+		images = append(images, img)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// renderChart - renders the chart templates (helm template) with the default values
+func renderChart(ch *chart.Chart) (string, error) {
+	vals := map[string]interface{}{}
+	if err := chartutil.ProcessDependencies(ch, vals); err != nil {
+		return "", err
+	}
+	options := chartutil.ReleaseOptions{Name: "mirror", Namespace: "default", IsInstall: true}
+	values, err := chartutil.ToRenderValues(ch, vals, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := engine.Render(ch, values)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, crd := range ch.CRDObjects() {
+		fmt.Fprintf(&sb, "---\n%s\n", crd.File.Data)
+	}
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasSuffix(name, "NOTES.txt") {
+			continue
+		}
+		fmt.Fprintf(&sb, "---\n%s\n", rendered[name])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,338 @@
+package helm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const (
+	testChartYaml = `apiVersion: v2
+name: podinfo
+version: 5.0.0
+`
+	testValuesYaml = `image:
+  repository: ghcr.io/stefanprodan/podinfo
+  tag: 5.0.0
+`
+	testDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox:1.36
+      containers:
+        - name: podinfo
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+`
+	testCustomResource = `apiVersion: example.com/v1
+kind: Example
+metadata:
+  name: example
+spec:
+  relatedImage: quay.io/example/operand@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea
+`
+)
+
+// createTestChart - writes a chart directory and returns its path
+func createTestChart(t *testing.T) string {
+	dir := t.TempDir() + "/podinfo"
+	files := map[string]string{
+		"Chart.yaml":          testChartYaml,
+		"values.yaml":         testValuesYaml,
+		"templates/app.yaml":  testDeployment,
+		"templates/cr.yaml":   testCustomResource,
+		"templates/NOTES.txt": "image: {{ .Values.image.repository }}",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(dir+"/templates", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dir+"/"+name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// withDestFlags - sets the destination image options of the --dest-* flags
+func withDestFlags(t *testing.T, opts mirror.CopyOptions, args ...string) mirror.CopyOptions {
+	_, shared := mirror.SharedImageFlags()
+	_, deprecated := mirror.DeprecatedTLSVerifyFlags()
+	fs, destOpts := mirror.ImageDestFlags(opts.Global, shared, deprecated, "dest-", "dcreds")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	opts.DestImage = destOpts
+	return opts
+}
+
+func TestHelmImageCollector(t *testing.T) {
+	log := clog.New("trace")
+
+	t.Run("Testing HelmImageCollector mirrorToDisk : should pass", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Helm.Local = []v1alpha2.Chart{
+			{Name: "podinfo", Path: createTestChart(t), ImagePaths: []string{"{.spec.relatedImage}"}},
+		}
+		opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: dir}, Mode: mirrorToDisk, Destination: "oci://test"}
+		ex := New(log, cfg, opts, nil, nil)
+		res, err := ex.HelmImageCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 3 {
+			t.Fatalf("should find 3 images got %v", res)
+		}
+		if res[0].Source != "docker://busybox:1.36" || res[0].Destination != "oci:"+dir+"/helm-images/library/busybox/1.36" {
+			t.Fatalf("should map the image to the cache dir got %v", res[0])
+		}
+		if _, err := os.Stat(dir + "/charts/local/podinfo-5.0.0.tgz"); err != nil {
+			t.Fatalf("should copy the chart to the charts dir %v", err)
+		}
+	})
+
+	t.Run("Testing HelmImageCollector diskToMirror : should pass", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, d := range []string{"test-lmz/helm-images/library/busybox/1.36", "test-lmz/helm-images/example/operand/sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"} {
+			if err := os.MkdirAll(dir+"/"+d, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(dir+"/"+d+"/"+indexJson, []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: t.TempDir(), From: dir}, Mode: diskToMirror, Destination: "docker://localhost:5000/test"}
+		ex := New(log, v1alpha2.ImageSetConfiguration{}, opts, nil, nil)
+		res, err := ex.HelmImageCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("should find 2 images got %v", res)
+		}
+		if res[0].Destination != "docker://localhost:5000/test/example/operand@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea" || !res[0].PreserveDigests {
+			t.Fatalf("should push by digest got %v", res[0])
+		}
+		if res[1].Destination != "docker://localhost:5000/test/library/busybox:1.36" {
+			t.Fatalf("should push by tag got %v", res[1])
+		}
+	})
+
+	t.Run("Testing HelmImageCollector diskToMirror publish charts : should pass", func(t *testing.T) {
+		// a charts dir that is not next to the helm images (i.e in an operator bundle) is not published
+		dir := t.TempDir()
+		for _, d := range []string{"test-lmz/helm-images", "test-lmz/operator-images/bundle/charts/sample"} {
+			if err := os.MkdirAll(dir+"/"+d, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(dir+"/test-lmz/operator-images/bundle/charts/sample/sample-1.0.0.tgz", []byte("not a chart"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Helm.PublishCharts = true
+		opts := withDestFlags(t, mirror.CopyOptions{Global: &mirror.GlobalOptions{Dir: t.TempDir(), From: dir}, Mode: diskToMirror, Destination: "docker://localhost:5000/test"})
+		ex := New(log, cfg, opts, nil, nil)
+		if _, err := ex.HelmImageCollector(context.Background()); err != nil {
+			t.Fatalf("should not publish the charts dir of a bundle %v", err)
+		}
+
+		// the working-dir/charts archives are published
+		if err := os.MkdirAll(dir+"/test-lmz/charts/local", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dir+"/test-lmz/charts/local/sample-1.0.0.tgz", []byte("not a chart"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ex.HelmImageCollector(context.Background()); err == nil {
+			t.Fatalf("should publish the charts of the working dir")
+		}
+	})
+}
+
+func TestDownloadChart(t *testing.T) {
+	ch, err := loader.Load(createTestChart(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := chartutil.Save(ch, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a repository (self signed certificate) that requires basic auth
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/index.yaml":
+			w.Write([]byte("apiVersion: v1\nentries:\n  podinfo:\n  - version: 4.0.0\n    urls: [podinfo-4.0.0.tgz]\n  - version: 5.0.0\n    urls: [podinfo-5.0.0.tgz]\n")) // nolint: errcheck
+		case "/podinfo-5.0.0.tgz":
+			w.Write(data) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	certDir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(certDir+"/ca.crt", ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+	authFile := t.TempDir() + "/auth.json"
+	auth := `{"auths":{"` + ts.Listener.Addr().String() + `":{"auth":"` + base64.StdEncoding.EncodeToString([]byte("user:pass")) + `"}}}`
+	if err := os.WriteFile(authFile, []byte(auth), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := newChartClient(&types.SystemContext{DockerCertPath: certDir, AuthFilePath: authFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Testing downloadChart latest : should pass", func(t *testing.T) {
+		path, err := downloadChart(client, ts.URL, v1alpha2.Chart{Name: "podinfo"}, t.TempDir())
+		if err != nil || !strings.HasSuffix(path, "podinfo-5.0.0.tgz") {
+			t.Fatalf("should download the latest version %s %v", path, err)
+		}
+	})
+
+	t.Run("Testing downloadChart missing version : should fail", func(t *testing.T) {
+		if _, err := downloadChart(client, ts.URL, v1alpha2.Chart{Name: "podinfo", Version: "6.0.0"}, t.TempDir()); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+
+	t.Run("Testing downloadChart untrusted certificate : should fail", func(t *testing.T) {
+		untrusted, err := newChartClient(&types.SystemContext{AuthFilePath: authFile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := downloadChart(untrusted, ts.URL, v1alpha2.Chart{Name: "podinfo"}, t.TempDir()); err == nil {
+			t.Fatalf("should verify the repository certificate")
+		}
+	})
+}
+
+func TestNewChartArtifact(t *testing.T) {
+	ch, err := loader.Load(createTestChart(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := chartutil.Save(ch, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, tag, err := newChartArtifact(archive)
+	if err != nil || tag != "podinfo:5.0.0" {
+		t.Fatalf("should create the artifact %s %v", tag, err)
+	}
+	raw, err := img.RawManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m v1.Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Config.MediaType != helmConfigMediaType || len(m.Layers) != 1 || m.Layers[0].MediaType != helmChartMediaType {
+		t.Fatalf("should use the helm media types got %s", raw)
+	}
+}
+
+func TestPublishCharts(t *testing.T) {
+	log := clog.New("trace")
+	ch, err := loader.Load(createTestChart(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(dir+"/local", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chartutil.Save(ch, dir+"/local"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a registry (self signed certificate) that requires basic auth and accepts every upload
+	var manifests int
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost || r.Method == http.MethodPatch:
+			w.Header().Set("Location", "/v2/test/charts/podinfo/blobs/uploads/1")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut:
+			if strings.Contains(r.URL.Path, "/manifests/") {
+				manifests++
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	certDir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(certDir+"/ca.crt", ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the options of the --dest-* flags
+	collector := func(args ...string) *Collector {
+		opts := mirror.CopyOptions{Global: &mirror.GlobalOptions{TlsVerify: false}, Mode: diskToMirror, Destination: "docker://" + ts.Listener.Addr().String() + "/test"}
+		opts = withDestFlags(t, opts, append(args, "--dest-creds=user:pass")...)
+		return &Collector{Log: log, Opts: opts}
+	}
+
+	t.Run("Testing publishCharts (tls verify, untrusted certificate) : should fail", func(t *testing.T) {
+		err := collector("--dest-tls-verify=true").publishCharts(dir)
+		if err == nil || manifests != 0 {
+			t.Fatalf("should not downgrade a tls verified push %v", err)
+		}
+	})
+
+	t.Run("Testing publishCharts (tls verify, cert dir) : should pass", func(t *testing.T) {
+		if err := collector("--dest-tls-verify=true", "--dest-cert-dir="+certDir).publishCharts(dir); err != nil || manifests != 1 {
+			t.Fatalf("should push the chart with the destination certificates and credentials %v", err)
+		}
+	})
+
+	t.Run("Testing publishCharts (no tls verify) : should pass", func(t *testing.T) {
+		if err := collector("--dest-tls-verify=false").publishCharts(dir); err != nil || manifests != 2 {
+			t.Fatalf("should push the chart %v", err)
+		}
+	})
+}
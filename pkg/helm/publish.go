@@ -0,0 +1,171 @@
+package helm
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/pkg/tlsclientconfig"
+	"github.com/containers/image/v5/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+const (
+	helmConfigMediaType ggcrtypes.MediaType = "application/vnd.cncf.helm.config.v1+json"
+	helmChartMediaType  ggcrtypes.MediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+)
+
+// chartArtifact - a helm chart as an OCI artifact (config is the chart metadata)
+type chartArtifact struct {
+	rawConfig []byte
+	config    v1.Layer
+	chart     v1.Layer
+}
+
+// newChartArtifact - creates the OCI artifact for a chart archive
+func newChartArtifact(archive string) (v1.Image, string, error) {
+	ch, err := loader.Load(archive)
+	if err != nil {
+		return nil, "", err
+	}
+	config, err := json.Marshal(ch.Metadata)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		return nil, "", err
+	}
+	img, err := partial.CompressedToImage(&chartArtifact{
+		rawConfig: config,
+		config:    static.NewLayer(config, helmConfigMediaType),
+		chart:     static.NewLayer(data, helmChartMediaType),
+	})
+	return img, ch.Metadata.Name + ":" + ch.Metadata.Version, err
+}
+
+// RawConfigFile - the chart metadata
+func (o *chartArtifact) RawConfigFile() ([]byte, error) {
+	return o.rawConfig, nil
+}
+
+// MediaType - artifacts use the OCI manifest media type
+func (o *chartArtifact) MediaType() (ggcrtypes.MediaType, error) {
+	return ggcrtypes.OCIManifestSchema1, nil
+}
+
+// RawManifest - the OCI manifest with the helm config and chart layer
+func (o *chartArtifact) RawManifest() ([]byte, error) {
+	config, err := partial.Descriptor(o.config)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := partial.Descriptor(o.chart)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     ggcrtypes.OCIManifestSchema1,
+		Config:        *config,
+		Layers:        []v1.Descriptor{*layer},
+	})
+}
+
+// LayerByDigest - returns the config or chart blob
+func (o *chartArtifact) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	for _, l := range []v1.Layer{o.config, o.chart} {
+		if d, err := l.Digest(); err == nil && d == h {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("blob %v not found", h)
+}
+
+// publishCharts - pushes the charts (archives) as OCI artifacts to <destination>/charts/<name>:<version>
+// the tls and credentials of the destination (--dest-* flags) are used as for the images
+func (o *Collector) publishCharts(dir string) error {
+	archives, err := filepath.Glob(dir + "/*/*.tgz")
+	if err != nil || len(archives) == 0 {
+		return err
+	}
+	sys, err := o.Opts.DestImage.NewSystemContext()
+	if err != nil {
+		return err
+	}
+	for _, archive := range archives {
+		img, tag, err := newChartArtifact(archive)
+		if err != nil {
+			return err
+		}
+		dest := strings.Join([]string{strings.TrimPrefix(o.Opts.Destination, dockerProtocol), chartsRepo, tag}, "/")
+		opts, nameOpts, err := registryOptions(sys, strings.Split(dest, "/")[0])
+		if err != nil {
+			return err
+		}
+		ref, err := name.ParseReference(dest, nameOpts...)
+		if err != nil {
+			return err
+		}
+		if err := remote.Write(ref, img, opts...); err != nil {
+			return err
+		}
+		o.Log.Info("chart %s published to %s", archive, dest)
+	}
+	return nil
+}
+
+// registryOptions - the transport (tls verify and certificates) and the credentials of a registry
+// from the system context of the destination
+func registryOptions(sys *types.SystemContext, registry string) ([]remote.Option, []name.Option, error) {
+	insecure := sys.DockerInsecureSkipTLSVerify == types.OptionalBoolTrue
+	tlsConfig, err := newTLSConfig(sys)
+	if err != nil {
+		return nil, nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	var nameOpts []name.Option
+	if insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+
+	// --dest-creds (or username and password), the registry token or the auth file
+	creds, err := config.GetCredentials(sys, registry)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth := authn.Anonymous
+	if creds != (types.DockerAuthConfig{}) || len(sys.DockerBearerRegistryToken) > 0 {
+		auth = authn.FromConfig(authn.AuthConfig{
+			Username:      creds.Username,
+			Password:      creds.Password,
+			IdentityToken: creds.IdentityToken,
+			RegistryToken: sys.DockerBearerRegistryToken,
+		})
+	}
+	return []remote.Option{remote.WithTransport(transport), remote.WithAuth(auth)}, nameOpts, nil
+}
+
+// newTLSConfig - the tls verification and certificates (cert dir) of a system context
+func newTLSConfig(sys *types.SystemContext) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: sys.DockerInsecureSkipTLSVerify == types.OptionalBoolTrue}
+	if len(sys.DockerCertPath) > 0 {
+		if err := tlsclientconfig.SetupCertificates(sys.DockerCertPath, tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	return tlsConfig, nil
+}
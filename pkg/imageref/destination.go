@@ -0,0 +1,66 @@
+package imageref
+
+import (
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+)
+
+const (
+	TypeRelease    string = "release"
+	TypeOperator   string = "operator"
+	TypeAdditional string = "additional"
+	TypeHelm       string = "helm"
+)
+
+// DestinationPath - applies the destination rules to a repository path with its tag or digest
+// (path:tag or path@digest), the result is appended to the destination
+func DestinationPath(rules v1alpha2.DestinationRules, imageType, ref string) string {
+	path, suffix := splitSuffix(strings.Trim(ref, "/"))
+
+	for _, r := range rules.PrefixRewrites {
+		from := strings.Trim(r.From, "/")
+		if path == from || strings.HasPrefix(path, from+"/") {
+			path = strings.Trim(strings.Trim(r.To, "/")+path[len(from):], "/")
+			break
+		}
+	}
+
+	if parts := strings.Split(path, "/"); rules.FlattenDepth > 0 && len(parts) > rules.FlattenDepth {
+		keep := rules.FlattenDepth - 1
+		flat := strings.Join(parts[:len(parts)-keep], "-")
+		path = strings.Join(append([]string{flat}, parts[len(parts)-keep:]...), "/")
+	}
+
+	if root := strings.Trim(destinationRoot(rules.Roots, imageType), "/"); len(root) > 0 {
+		path = root + "/" + path
+	}
+	return path + suffix
+}
+
+// destinationRoot - the root path of the image type
+func destinationRoot(roots v1alpha2.DestinationRoots, imageType string) string {
+	switch imageType {
+	case TypeRelease:
+		return roots.Release
+	case TypeOperator:
+		return roots.Operators
+	case TypeAdditional:
+		return roots.AdditionalImages
+	case TypeHelm:
+		return roots.Helm
+	default:
+		return ""
+	}
+}
+
+// splitSuffix - splits a reference into the repository path and the :tag or @digest suffix
+func splitSuffix(ref string) (string, string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i:]
+	}
+	return ref, ""
+}
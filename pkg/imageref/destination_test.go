@@ -0,0 +1,79 @@
+package imageref
+
+import (
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationPath(t *testing.T) {
+	type spec struct {
+		name      string
+		rules     v1alpha2.DestinationRules
+		imageType string
+		ref       string
+		expected  string
+	}
+	cases := []spec{
+		{name: "no rules", imageType: TypeAdditional, ref: "/a/b/c/app:v1", expected: "a/b/c/app:v1"},
+		{
+			name:      "prefix rewrite",
+			rules:     v1alpha2.DestinationRules{PrefixRewrites: []v1alpha2.PrefixRewrite{{From: "openshift-release-dev/", To: "ocp"}, {From: "openshift-release-dev", To: "other"}}},
+			imageType: TypeRelease,
+			ref:       "openshift-release-dev/ocp-release:4.14.1-x86_64",
+			expected:  "ocp/ocp-release:4.14.1-x86_64",
+		},
+		{
+			name:      "prefix rewrite (no partial match)",
+			rules:     v1alpha2.DestinationRules{PrefixRewrites: []v1alpha2.PrefixRewrite{{From: "team", To: "ocp"}}},
+			imageType: TypeAdditional,
+			ref:       "teams/app:v1",
+			expected:  "teams/app:v1",
+		},
+		{
+			name:      "flatten",
+			rules:     v1alpha2.DestinationRules{FlattenDepth: 2},
+			imageType: TypeOperator,
+			ref:       "a/b/c/app@" + testDigest,
+			expected:  "a-b-c/app@" + testDigest,
+		},
+		{
+			name:      "flatten to one level",
+			rules:     v1alpha2.DestinationRules{FlattenDepth: 1},
+			imageType: TypeOperator,
+			ref:       "a/b/app:v1",
+			expected:  "a-b-app:v1",
+		},
+		{
+			name:      "flatten (short path)",
+			rules:     v1alpha2.DestinationRules{FlattenDepth: 3},
+			imageType: TypeOperator,
+			ref:       "b/app:v1",
+			expected:  "b/app:v1",
+		},
+		{
+			name: "all rules",
+			rules: v1alpha2.DestinationRules{
+				PrefixRewrites: []v1alpha2.PrefixRewrite{{From: "org/team", To: "x/y/z"}},
+				FlattenDepth:   2,
+				Roots:          v1alpha2.DestinationRoots{AdditionalImages: "/mirror/extra/", Helm: "charts"},
+			},
+			imageType: TypeAdditional,
+			ref:       "org/team/app:v1",
+			expected:  "mirror/extra/x-y-z/app:v1",
+		},
+		{
+			name:      "root of another type",
+			rules:     v1alpha2.DestinationRules{Roots: v1alpha2.DestinationRoots{AdditionalImages: "extra"}},
+			imageType: TypeHelm,
+			ref:       "org/app:v1",
+			expected:  "org/app:v1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expected, DestinationPath(c.rules, c.imageType, c.ref))
+		})
+	}
+}
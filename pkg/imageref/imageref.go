@@ -0,0 +1,82 @@
+package imageref
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+)
+
+const (
+	dockerProtocol string = "docker://"
+	digestPrefix   string = "sha256-"
+	latestTag      string = "latest"
+)
+
+// digestDir - a cache directory of a digest pinned image (sha256-<hash>)
+var digestDir = regexp.MustCompile(`^` + digestPrefix + `[0-9a-f]{64}$`)
+
+// Parse - parses an image reference (with an optional docker:// prefix)
+// i.e localhost:5000/a/b/c:v1 -> Repository localhost:5000, Namespace a/b, Component c, Tag v1
+// short names are normalized (busybox -> docker.io/library/busybox)
+func Parse(image string) (*v1alpha3.ImageRefSchema, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(image, dockerProtocol))
+	if err != nil {
+		return nil, fmt.Errorf("[imageref] image url seems to be wrong %s : %v", image, err)
+	}
+	irs := &v1alpha3.ImageRefSchema{Repository: reference.Domain(named), Path: reference.Path(named)}
+	irs.Component = irs.Path
+	if i := strings.LastIndex(irs.Path, "/"); i >= 0 {
+		irs.Namespace = irs.Path[:i]
+		irs.Component = irs.Path[i+1:]
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		irs.Tag = tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		irs.Digest = digested.Digest().String()
+	}
+	return irs, nil
+}
+
+// Dir - returns the (relative) cache directory of an image
+// the path (without registry) with the tag as a sub directory, or the digest as sha256-<hash>
+// i.e quay.io/org/team/app@sha256:abc -> org/team/app/sha256-abc
+func Dir(irs *v1alpha3.ImageRefSchema) string {
+	switch {
+	case len(irs.Digest) > 0:
+		return irs.Path + "/" + strings.Replace(irs.Digest, ":", "-", 1)
+	case len(irs.Tag) > 0:
+		return irs.Path + "/" + irs.Tag
+	default:
+		return irs.Path + "/" + latestTag
+	}
+}
+
+// FromDir - converts a cache directory (see Dir) back to an image reference (path:tag or path@digest)
+func FromDir(dir string) string {
+	dir = strings.Trim(dir, "/")
+	i := strings.LastIndex(dir, "/")
+	if i < 0 {
+		return dir
+	}
+	// a tag can start with sha256- (i.e cosign sha256-<hash>.sig)
+	if digestDir.MatchString(dir[i+1:]) {
+		return dir[:i] + "@" + strings.Replace(dir[i+1:], "-", ":", 1)
+	}
+	return dir[:i] + ":" + dir[i+1:]
+}
+
+// Reference - returns the full image reference (registry/path:tag or registry/path@digest)
+func Reference(irs *v1alpha3.ImageRefSchema) string {
+	ref := irs.Repository + "/" + irs.Path
+	if len(irs.Digest) > 0 {
+		return ref + "@" + irs.Digest
+	}
+	if len(irs.Tag) > 0 {
+		return ref + ":" + irs.Tag
+	}
+	return ref
+}
@@ -0,0 +1,86 @@
+package imageref
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest string = "sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"
+
+func TestParse(t *testing.T) {
+	type spec struct {
+		image    string
+		expected v1alpha3.ImageRefSchema
+		dir      string
+	}
+	cases := []spec{
+		{
+			image:    "registry.redhat.io/ubi8/ubi:latest",
+			expected: v1alpha3.ImageRefSchema{Repository: "registry.redhat.io", Namespace: "ubi8", Component: "ubi", Path: "ubi8/ubi", Tag: "latest"},
+			dir:      "ubi8/ubi/latest",
+		},
+		{
+			image:    "localhost:5000/a/b/c:tag",
+			expected: v1alpha3.ImageRefSchema{Repository: "localhost:5000", Namespace: "a/b", Component: "c", Path: "a/b/c", Tag: "tag"},
+			dir:      "a/b/c/tag",
+		},
+		{
+			image:    "docker://quay.io/org/team/app@" + testDigest,
+			expected: v1alpha3.ImageRefSchema{Repository: "quay.io", Namespace: "org/team", Component: "app", Path: "org/team/app", Digest: testDigest},
+			dir:      "org/team/app/sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea",
+		},
+		{
+			image:    "quay.io/org/app:v1@" + testDigest,
+			expected: v1alpha3.ImageRefSchema{Repository: "quay.io", Namespace: "org", Component: "app", Path: "org/app", Tag: "v1", Digest: testDigest},
+			dir:      "org/app/sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea",
+		},
+		{
+			image:    "busybox",
+			expected: v1alpha3.ImageRefSchema{Repository: "docker.io", Namespace: "library", Component: "busybox", Path: "library/busybox"},
+			dir:      "library/busybox/latest",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			irs, err := Parse(c.image)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, *irs)
+			require.Equal(t, c.dir, Dir(irs))
+		})
+	}
+
+	_, err := Parse("quay.io/UPPER/app")
+	require.Error(t, err)
+	_, err = Parse("quay.io/org/app@sha256:abc")
+	require.Error(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	images := []string{
+		"registry.redhat.io/ubi8/ubi:latest",
+		"localhost:5000/a/b/c:tag",
+		"quay.io/org/team/app@" + testDigest,
+		"quay.io/org/app:1.2.3-rc.1",
+	}
+	for _, image := range images {
+		irs, err := Parse(image)
+		require.NoError(t, err)
+		require.Equal(t, image, Reference(irs))
+
+		// the cache dir maps back to the same repository path and tag (or digest)
+		back, err := Parse(irs.Repository + "/" + FromDir("/"+Dir(irs)))
+		require.NoError(t, err)
+		require.Equal(t, irs, back)
+	}
+}
+
+func TestFromDir(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	require.Equal(t, "quay.io/org/app@sha256:"+hash, FromDir("quay.io/org/app/sha256-"+hash))
+	require.Equal(t, "quay.io/org/app:sha256-"+hash+".sig", FromDir("/quay.io/org/app/sha256-"+hash+".sig"))
+	require.Equal(t, "quay.io/org/app:sha256-abc", FromDir("quay.io/org/app/sha256-abc"))
+	require.Equal(t, "quay.io/org/app:v1", FromDir("quay.io/org/app/v1/"))
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FormatText       string = "text"
+	FormatJSON       string = "json"
+	defaultComponent string = "mirror"
+	componentKey     string = "component"
+)
+
+// component - a leading [Component] of a message (i.e "[Worker] error in batch")
+var component = regexp.MustCompile(`^\s*\[([A-Za-z0-9_-]+)\]\s*`)
+
+// levels - the levels logged for each log level (error is always logged)
+var levels = map[string][]string{
+	"error": {"error"},
+	"warn":  {"error", "warn"},
+	"info":  {"error", "warn", "info"},
+	"debug": {"error", "warn", "info", "debug"},
+	"trace": {"error", "warn", "info", "debug", "trace"},
+}
+
+// jsonOutput - the writer and level shared by a JSONLogger and the loggers derived from it (With)
+type jsonOutput struct {
+	out   io.Writer
+	level string
+	mu    sync.Mutex
+}
+
+// JSONLogger - logs JSON lines with the level, timestamp, component, message and fields
+// i.e {"component":"Worker","image":"docker://quay.io/org/app:v1","level":"error","msg":"...","timestamp":"..."}
+type JSONLogger struct {
+	output *jsonOutput
+	fields map[string]string
+}
+
+// NewJSON - returns a new JSONLogger instance writing to out
+func NewJSON(out io.Writer, level string) *JSONLogger {
+	return &JSONLogger{output: &jsonOutput{out: out, level: level}, fields: map[string]string{}}
+}
+
+// Error
+func (c *JSONLogger) Error(msg string, val ...interface{}) {
+	c.write("error", msg, val...)
+}
+
+// Info
+func (c *JSONLogger) Info(msg string, val ...interface{}) {
+	c.write("info", msg, val...)
+}
+
+// Debug
+func (c *JSONLogger) Debug(msg string, val ...interface{}) {
+	c.write("debug", msg, val...)
+}
+
+// Trace
+func (c *JSONLogger) Trace(msg string, val ...interface{}) {
+	c.write("trace", msg, val...)
+}
+
+// Warn
+func (c *JSONLogger) Warn(msg string, val ...interface{}) {
+	c.write("warn", msg, val...)
+}
+
+// Level - ovveride log level
+func (c *JSONLogger) Level(level string) {
+	c.output.mu.Lock()
+	defer c.output.mu.Unlock()
+	c.output.level = level
+}
+
+// Format - the JSONLogger only logs json
+func (c *JSONLogger) Format(format string) error {
+	if format != FormatJSON {
+		return fmt.Errorf("[Log] json logger can't log with format %s ", format)
+	}
+	return nil
+}
+
+// With - returns a logger that adds the key/value pairs to each line ("component" sets the component)
+func (c *JSONLogger) With(keyValues ...string) PluggableLoggerInterface {
+	fields := make(map[string]string, len(c.fields)+len(keyValues)/2)
+	for k, v := range c.fields {
+		fields[k] = v
+	}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		fields[keyValues[i]] = keyValues[i+1]
+	}
+	return &JSONLogger{output: c.output, fields: fields}
+}
+
+// write - writes a line if the level is logged, a leading [Component] of the message sets the component
+func (c *JSONLogger) write(level, msg string, val ...interface{}) {
+	c.output.mu.Lock()
+	defer c.output.mu.Unlock()
+	if !logged(c.output.level, level) {
+		return
+	}
+	line := make(map[string]string, len(c.fields)+4)
+	for k, v := range c.fields {
+		line[k] = v
+	}
+	if _, ok := line[componentKey]; !ok {
+		line[componentKey] = defaultComponent
+	}
+	text := fmt.Sprintf(msg, val...)
+	if m := component.FindStringSubmatch(text); m != nil {
+		line[componentKey] = m[1]
+		text = text[len(m[0]):]
+	}
+	line["level"] = level
+	line["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["msg"] = strings.TrimSpace(text)
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	c.output.out.Write(append(data, '\n'))
+}
+
+func logged(logLevel, level string) bool {
+	for _, l := range levels[logLevel] {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONLogger(t *testing.T) {
+
+	t.Run("Testing JSONLogger : should pass", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		log := NewJSON(out, "info")
+		log.Info("mode %s ", "mirrorToDisk")
+		log.Debug("not logged at info level")
+		With(log, "image", "docker://quay.io/org/app:v1", "batch", "0").Error("[Worker] %v", "forced error")
+		log.Level("trace")
+		With(log, "component", "collector", "collector", "release").Trace("copying %s", "release")
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("should log 3 lines %v", lines)
+		}
+		expected := []map[string]string{
+			{"level": "info", "component": "mirror", "msg": "mode mirrorToDisk"},
+			{"level": "error", "component": "Worker", "msg": "forced error", "image": "docker://quay.io/org/app:v1", "batch": "0"},
+			{"level": "trace", "component": "collector", "msg": "copying release", "collector": "release"},
+		}
+		for i, line := range lines {
+			var res map[string]string
+			if err := json.Unmarshal([]byte(line), &res); err != nil {
+				t.Fatalf("should log json lines %v", err)
+			}
+			if len(res["timestamp"]) == 0 {
+				t.Fatalf("should log the timestamp %v", res)
+			}
+			delete(res, "timestamp")
+			if len(res) != len(expected[i]) {
+				t.Fatalf("should log %v got %v", expected[i], res)
+			}
+			for k, v := range expected[i] {
+				if res[k] != v {
+					t.Fatalf("should log %v got %v", expected[i], res)
+				}
+			}
+		}
+		if log.Format(FormatText) == nil {
+			t.Fatalf("should only log json")
+		}
+	})
+
+	t.Run("Testing PluggableLogger (format) : should pass", func(t *testing.T) {
+		log := New("info").(*PluggableLogger)
+		if With(log, "image", "a") != log {
+			t.Fatalf("text format should have no fields")
+		}
+		if err := log.Format(FormatJSON); err != nil {
+			t.Fatalf("should select the json format %v", err)
+		}
+		out := &bytes.Buffer{}
+		log.json.output.out = out
+		With(log, "image", "a").Warn("warn %d", 1)
+		log.Info("info")
+		log.Level("error")
+		log.Info("not logged")
+		if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[0], `"image":"a"`) {
+			t.Fatalf("should log json lines %v", lines)
+		}
+		if err := log.Format("xml"); err == nil {
+			t.Fatalf("should fail for an unknown format")
+		}
+		if err := log.Format(FormatText); err != nil || log.json != nil {
+			t.Fatalf("should select the text format %v", err)
+		}
+	})
+}
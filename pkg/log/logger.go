@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/microlib/simple"
 )
@@ -15,11 +16,18 @@ type PluggableLoggerInterface interface {
 	Trace(msg string, val ...interface{})
 	Warn(msg string, val ...interface{})
 	Level(levele string)
+	Format(format string) error
+}
+
+// FieldLoggerInterface - a logger that can add key/value fields to its lines (see With)
+type FieldLoggerInterface interface {
+	With(keyValues ...string) PluggableLoggerInterface
 }
 
 // PluggableLogger
 type PluggableLogger struct {
-	Log *simple.Logger
+	Log  *simple.Logger
+	json *JSONLogger
 }
 
 // New - returns a new PluggableLogger instance
@@ -27,32 +35,85 @@ func New(level string) PluggableLoggerInterface {
 	return &PluggableLogger{Log: &simple.Logger{Level: level}}
 }
 
+// With - adds the key/value pairs (i.e "image", "quay.io/org/app:v1") to the lines of the logger
+// if it supports fields (the json format), otherwise the logger is returned as is
+func With(log PluggableLoggerInterface, keyValues ...string) PluggableLoggerInterface {
+	if fl, ok := log.(FieldLoggerInterface); ok {
+		return fl.With(keyValues...)
+	}
+	return log
+}
+
 // Error
 func (c *PluggableLogger) Error(msg string, val ...interface{}) {
+	if c.json != nil {
+		c.json.Error(msg, val...)
+		return
+	}
 	c.Log.Error(fmt.Sprintf(msg, val...))
 }
 
 // Info
 func (c *PluggableLogger) Info(msg string, val ...interface{}) {
+	if c.json != nil {
+		c.json.Info(msg, val...)
+		return
+	}
 	c.Log.Info(fmt.Sprintf(msg, val...))
 }
 
 // Debug
 func (c *PluggableLogger) Debug(msg string, val ...interface{}) {
+	if c.json != nil {
+		c.json.Debug(msg, val...)
+		return
+	}
 	c.Log.Debug(fmt.Sprintf(msg, val...))
 }
 
 // Trace
 func (c *PluggableLogger) Trace(msg string, val ...interface{}) {
+	if c.json != nil {
+		c.json.Trace(msg, val...)
+		return
+	}
 	c.Log.Trace(fmt.Sprintf(msg, val...))
 }
 
 // Warn
 func (c *PluggableLogger) Warn(msg string, val ...interface{}) {
+	if c.json != nil {
+		c.json.Warn(msg, val...)
+		return
+	}
 	c.Log.Warn(fmt.Sprintf(msg, val...))
 }
 
 // Level - ovveride log level
 func (c *PluggableLogger) Level(level string) {
 	c.Log.Level = level
+	if c.json != nil {
+		c.json.Level(level)
+	}
+}
+
+// Format - text (the default) or json lines (see JSONLogger) written to stderr
+func (c *PluggableLogger) Format(format string) error {
+	switch format {
+	case FormatText, "":
+		c.json = nil
+	case FormatJSON:
+		c.json = NewJSON(os.Stderr, c.Log.Level)
+	default:
+		return fmt.Errorf("[Log] unknown log format %s (text or json) ", format)
+	}
+	return nil
+}
+
+// With - adds fields to the json lines, the text format has no fields
+func (c *PluggableLogger) With(keyValues ...string) PluggableLoggerInterface {
+	if c.json == nil {
+		return c
+	}
+	return &PluggableLogger{Log: c.Log, json: c.json.With(keyValues...).(*JSONLogger)}
 }
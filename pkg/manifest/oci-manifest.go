@@ -174,7 +174,8 @@ func (o *Manifest) GetReleaseSchema(filePath string) ([]v1alpha3.RelatedImage, e
 
 	var allImages []v1alpha3.RelatedImage
 	for _, item := range release.Spec.Tags {
-		allImages = append(allImages, v1alpha3.RelatedImage{Image: item.From.Name, Name: item.Name})
+		annotations := item.Annotations
+		allImages = append(allImages, v1alpha3.RelatedImage{Image: item.From.Name, Name: item.Name, Annotations: &annotations})
 	}
 	return allImages, nil
 }
@@ -0,0 +1,59 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/opencontainers/go-digest"
+)
+
+// DigestInterface - resolves the manifest digest of images
+type DigestInterface interface {
+	// LocalDigest - the digest of the (top level) manifest of a local (oci: or dir:) image
+	LocalDigest(ctx context.Context, image string, opts *CopyOptions) (digest.Digest, error)
+	// RemoteDigest - the digest of a docker:// image (HEAD request on the manifest)
+	RemoteDigest(ctx context.Context, image string, opts *CopyOptions) (digest.Digest, error)
+}
+
+type ImageDigest struct{}
+
+// NewImageDigest - returns a new ImageDigest instance
+func NewImageDigest() DigestInterface {
+	return &ImageDigest{}
+}
+
+func (o *ImageDigest) LocalDigest(ctx context.Context, image string, opts *CopyOptions) (digest.Digest, error) {
+	ref, err := alltransports.ParseImageName(image)
+	if err != nil {
+		return "", fmt.Errorf("Invalid source name %s: %v", image, err)
+	}
+	sysCtx, err := opts.SrcImage.NewSystemContext()
+	if err != nil {
+		return "", err
+	}
+	src, err := ref.NewImageSource(ctx, sysCtx)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	mb, _, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	return manifest.Digest(mb)
+}
+
+func (o *ImageDigest) RemoteDigest(ctx context.Context, image string, opts *CopyOptions) (digest.Digest, error) {
+	ref, err := alltransports.ParseImageName(image)
+	if err != nil {
+		return "", fmt.Errorf("Invalid destination name %s: %v", image, err)
+	}
+	sysCtx, err := opts.DestImage.NewSystemContext()
+	if err != nil {
+		return "", err
+	}
+	return docker.GetDigest(ctx, sysCtx, ref)
+}
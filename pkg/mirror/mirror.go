@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/containers/common/pkg/retry"
 	"github.com/containers/image/v5/copy"
@@ -16,11 +17,13 @@ import (
 	"github.com/containers/image/v5/transports/alltransports"
 	"github.com/containers/image/v5/types"
 	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
 )
 
 const (
-	mirrorToDisk = "mirrorToDisk"
-	diskToMirror = "diskToMirror"
+	mirrorToDisk     = "mirrorToDisk"
+	diskToMirror     = "diskToMirror"
+	progressInterval = time.Second
 )
 
 // MirrorInterface  used to mirror images with container/images (skopeo)
@@ -159,6 +162,22 @@ func (o *Mirror) copy(ctx context.Context, src, dest string, opts *CopyOptions,
 		imageListSelection = copy.CopyAllImages
 	}
 
+	// copy only the instances of a manifest list for the architectures
+	var instances []digest.Digest
+	var sourceList []byte
+	if len(opts.Architectures) > 0 {
+		if len(opts.MultiArch) > 0 || opts.All {
+			return fmt.Errorf("Cannot use architectures with --all and --multi-arch flags")
+		}
+		instances, sourceList, err = platformInstances(ctx, srcRef, sourceCtx, opts.Architectures)
+		if err != nil {
+			return fmt.Errorf("Invalid source manifest list %s: %v", src, err)
+		}
+		if len(instances) > 0 {
+			imageListSelection = copy.CopySpecificImages
+		}
+	}
+
 	if len(opts.EncryptionKeys) > 0 && len(opts.DecryptionKeys) > 0 {
 		return fmt.Errorf("--encryption-key and --decryption-key cannot be specified together")
 	}
@@ -237,13 +256,25 @@ func (o *Mirror) copy(ctx context.Context, src, dest string, opts *CopyOptions,
 		DestinationCtx:                   destinationCtx,
 		ForceManifestMIMEType:            manifestType,
 		ImageListSelection:               imageListSelection,
+		Instances:                        instances,
 		PreserveDigests:                  opts.PreserveDigests,
 		//OciDecryptConfig:                 decConfig,
 		//OciEncryptLayers:                 encLayers,
 		//OciEncryptConfig:                 encConfig,
 	}
 
+	attempts := 0
 	return retry.IfNecessary(ctx, func() error {
+		attempts++
+		if opts.Result != nil {
+			*opts.Result = CopyResult{Retries: attempts - 1}
+			co.Progress, co.ProgressInterval = make(chan types.ProgressProperties), progressInterval
+			done := copyProgress(co.Progress, opts.Result)
+			defer func() {
+				close(co.Progress)
+				<-done
+			}()
+		}
 
 		//manifestBytes, err := copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
 		manifestBytes, err := o.mc.CopyImage(ctx, policyContext, destRef, srcRef, co)
@@ -251,6 +282,26 @@ func (o *Mirror) copy(ctx context.Context, src, dest string, opts *CopyOptions,
 			return err
 		}
 		out.Flush()
+		if len(instances) > 0 {
+			var trimmed []byte
+			switch destRef.Transport().Name() {
+			case "oci":
+				trimmed, err = trimLayoutIndex(layoutDir(dest), manifestBytes, sourceList, instances)
+			case "dir":
+				trimmed, err = trimDirManifest(destRef.StringWithinTransport(), manifestBytes, sourceList, instances)
+			}
+			if err != nil {
+				return err
+			}
+			if trimmed != nil {
+				manifestBytes = trimmed
+			}
+		}
+		if opts.Result != nil {
+			if d, err := manifest.Digest(manifestBytes); err == nil {
+				opts.Result.Digest = d.String()
+			}
+		}
 		if opts.DigestFile != "" {
 			manifestDigest, err := manifest.Digest(manifestBytes)
 			if err != nil {
@@ -264,6 +315,20 @@ func (o *Mirror) copy(ctx context.Context, src, dest string, opts *CopyOptions,
 	}, opts.RetryOpts)
 }
 
+// copyProgress - adds the bytes of each blob copied to the result, until the progress channel is closed
+func copyProgress(progress <-chan types.ProgressProperties, result *CopyResult) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range progress {
+			if p.Event == types.ProgressEventDone {
+				result.Size += int64(p.Offset)
+			}
+		}
+	}()
+	return done
+}
+
 // delete - delete images
 func (o *Mirror) delete(ctx context.Context, image string, opts *CopyOptions) error {
 
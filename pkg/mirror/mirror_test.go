@@ -9,6 +9,7 @@ import (
 	"github.com/containers/image/v5/copy"
 	"github.com/containers/image/v5/signature"
 	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
 )
 
 func TestMirror(t *testing.T) {
@@ -45,6 +46,18 @@ func TestMirror(t *testing.T) {
 			t.Fatal("should pass")
 		}
 	})
+
+	t.Run("Testing Worker (result) : should pass", func(t *testing.T) {
+		resultOpts := opts
+		resultOpts.Result = &CopyResult{}
+		err := m.Run(context.Background(), "docker://localhost.localdomain:5000/test", "oci:test", "copy", &resultOpts, *writer)
+		if err != nil {
+			t.Fatal("should pass")
+		}
+		if resultOpts.Result.Digest != digest.FromString("test").String() || resultOpts.Result.Size != 10 || resultOpts.Result.Retries != 0 {
+			t.Fatalf("should set the digest and size of the copy %v", resultOpts.Result)
+		}
+	})
 }
 
 // mock
@@ -53,6 +66,9 @@ type mockMirrorCopy struct{}
 type mockMirrorDelete struct{}
 
 func (o *mockMirrorCopy) CopyImage(ctx context.Context, pc *signature.PolicyContext, destRef, srcRef types.ImageReference, opts *copy.Options) ([]byte, error) {
+	if opts.Progress != nil {
+		opts.Progress <- types.ProgressProperties{Event: types.ProgressEventDone, Offset: 10}
+	}
 	return []byte("test"), nil
 }
 
@@ -451,6 +451,7 @@ func PromptForPassphrase(privateKeyFile string, stdin, stdout *os.File) (string,
 
 type GlobalOptions struct {
 	LogLevel           string        // one of info, debug, trace
+	LogFormat          string        // text or json (json lines)
 	TlsVerify          bool          // Require HTTPS and verify certificates (for docker: and docker-daemon:)
 	PolicyPath         string        // Path to a signature verification policy file
 	InsecurePolicy     bool          // Use an "allow everything" signature verification policy
@@ -466,8 +467,17 @@ type GlobalOptions struct {
 	ReleaseFrom        string        // Used for release mirroring (diskToMirror)
 	OperatorsFrom      string        // Used for operators mirroring (diskToMirror)
 	AdditionalFrom     string        // Used for additionalImages mirroring (diskToMirror)
+	From               string        // Working dir the releases, operators and additional images are discovered from (diskToMirror)
 	Quiet              bool          // Suppress output information when copying images
 	Force              bool          // Force the copy/mirror even if there is nothing to update
+	BlobStore          string        // Share the blobs of the cached images (global or type)
+	DryRun             bool          // Estimate and list the images to copy without copying them
+	SignKey            string        // Armored private key to sign the SHA256SUMS of the working dir (mirrorToDisk)
+	SignPassphraseFile string        // File with the passphrase of the sign key
+	VerifyKey          string        // Armored public key the SHA256SUMS signature must be valid for (diskToMirror)
+	SkipChecksums      bool          // Don't verify the SHA256SUMS of the working dir (diskToMirror)
+	LogRetention       int           // Number of run logs dirs to keep (0 keeps all)
+	LogsDir            string        // Logs dir of the current run (logs/<timestamp>-<mode>)
 }
 
 type CopyOptions struct {
@@ -486,6 +496,7 @@ type CopyOptions struct {
 	Format                   string    // Force conversion of the image to a specified format
 	All                      bool      // Copy all of the images if the source is a list
 	MultiArch                string    // How to handle multi architecture images
+	Architectures            []string  // Copy only these architectures of a manifest list
 	PreserveDigests          bool      // Preserve digests during copy
 	EncryptLayer             []int     // The list of layers to encrypt
 	EncryptionKeys           []string  // Keys needed to encrypt the image
@@ -495,4 +506,13 @@ type CopyOptions struct {
 	Destination              string    // what to target to
 	UUID                     uuid.UUID // set uuid
 	ImageType                string    // release, catalog-operator, additionalImage
+
+	Result *CopyResult // Filled in with the outcome of the copy when set
+}
+
+// CopyResult - the outcome of an image copy
+type CopyResult struct {
+	Digest  string // digest of the copied (top level) manifest
+	Size    int64  // bytes of the blobs copied (the blobs already at the destination are not counted)
+	Retries int    // number of retries of the copy
 }
@@ -0,0 +1,179 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	platformOS string = "linux"
+)
+
+// platformInstances - resolves the instance digests of the source manifest list for the architectures
+// and returns the source manifest list, returns nil if the source is not a manifest list
+func platformInstances(ctx context.Context, ref types.ImageReference, sysCtx *types.SystemContext, archs []string) ([]digest.Digest, []byte, error) {
+	src, err := ref.NewImageSource(ctx, sysCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer src.Close()
+	mb, mt, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	instances, err := SelectInstances(mb, mt, archs)
+	return instances, mb, err
+}
+
+// SelectInstances - returns the digests of the (linux) instances matching the architectures
+// returns nil if the manifest is not a manifest list
+func SelectInstances(mb []byte, mt string, archs []string) ([]digest.Digest, error) {
+	if !manifest.MIMETypeIsMultiImage(mt) {
+		return nil, nil
+	}
+	list, err := manifest.ListFromBlob(mb, mt)
+	if err != nil {
+		return nil, err
+	}
+	var instances []digest.Digest
+	for _, arch := range archs {
+		d, err := list.ChooseInstance(&types.SystemContext{ArchitectureChoice: arch, OSChoice: platformOS})
+		if err != nil {
+			return nil, fmt.Errorf("architecture %s : %v", arch, err)
+		}
+		if !containsDigest(instances, d) {
+			instances = append(instances, d)
+		}
+	}
+	return instances, nil
+}
+
+// trimList - removes the instances that were not copied from the (destination) manifest list or image index
+// the copied instances are the instances of the source list (in the same order) selected for the copy
+// (a converted instance has a new digest), returns nil if list is not a manifest list
+func trimList(list, source []byte, instances []digest.Digest) ([]byte, error) {
+	if !manifest.MIMETypeIsMultiImage(manifest.GuessMIMEType(list)) {
+		return nil, nil
+	}
+	// keep the other fields of the list as is
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(list, &fields); err != nil {
+		return nil, err
+	}
+	var descriptors []json.RawMessage
+	if err := json.Unmarshal(fields["manifests"], &descriptors); err != nil {
+		return nil, err
+	}
+	sourceDigests, err := listDigests(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(sourceDigests) != len(descriptors) {
+		return nil, fmt.Errorf("the manifest list has %d instances, the source list %d", len(descriptors), len(sourceDigests))
+	}
+	manifests := []json.RawMessage{}
+	for i, d := range descriptors {
+		if containsDigest(instances, sourceDigests[i]) {
+			manifests = append(manifests, d)
+		}
+	}
+	data, err := json.Marshal(manifests)
+	if err != nil {
+		return nil, err
+	}
+	fields["manifests"] = data
+	return json.Marshal(fields)
+}
+
+// listDigests - the instance digests of a manifest list or an image index (in order)
+func listDigests(list []byte) ([]digest.Digest, error) {
+	var idx struct {
+		Manifests []imgspecv1.Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(list, &idx); err != nil {
+		return nil, err
+	}
+	var digests []digest.Digest
+	for _, m := range idx.Manifests {
+		digests = append(digests, m.Digest)
+	}
+	return digests, nil
+}
+
+// trimLayoutIndex - removes the instances that were not copied from the image index in an oci layout
+// containers/image keeps all the instances in the index when copying specific images
+// returns the trimmed index (nil if list is not a manifest list)
+func trimLayoutIndex(dir string, list, source []byte, instances []digest.Digest) ([]byte, error) {
+	trimmed, err := trimList(list, source, instances)
+	if err != nil || trimmed == nil {
+		return nil, err
+	}
+
+	// write the trimmed index blob and point the layout index.json to it
+	oldDigest := digest.FromBytes(list)
+	newDigest := digest.FromBytes(trimmed)
+	blobs := filepath.Join(dir, "blobs", newDigest.Algorithm().String())
+	if err := os.WriteFile(filepath.Join(blobs, newDigest.Encoded()), trimmed, 0644); err != nil {
+		return nil, err
+	}
+	var layout imgspecv1.Index
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, err
+	}
+	for i, m := range layout.Manifests {
+		if m.Digest == oldDigest {
+			layout.Manifests[i].Digest = newDigest
+			layout.Manifests[i].Size = int64(len(trimmed))
+		}
+	}
+	data, err = json.Marshal(layout)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), data, 0644); err != nil {
+		return nil, err
+	}
+	return trimmed, os.Remove(filepath.Join(blobs, oldDigest.Encoded()))
+}
+
+// trimDirManifest - removes the instances that were not copied from the manifest list of a dir: image
+// (manifest.json), the copied instances are in <hash>.manifest.json
+// returns the trimmed list (nil if list is not a manifest list)
+func trimDirManifest(dir string, list, source []byte, instances []digest.Digest) ([]byte, error) {
+	trimmed, err := trimList(list, source, instances)
+	if err != nil || trimmed == nil {
+		return nil, err
+	}
+	return trimmed, os.WriteFile(filepath.Join(dir, "manifest.json"), trimmed, 0644)
+}
+
+// layoutDir - the directory of an oci layout reference (oci:path[:tag])
+func layoutDir(ref string) string {
+	dir := strings.TrimPrefix(strings.TrimPrefix(ref, "oci:"), "//")
+	if i := strings.Index(dir, ":"); i >= 0 {
+		dir = dir[:i]
+	}
+	return dir
+}
+
+func containsDigest(digests []digest.Digest, d digest.Digest) bool {
+	for _, x := range digests {
+		if x == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package mirror
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	amd64Digest   digest.Digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	arm64Digest   digest.Digest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	ppc64leDigest digest.Digest = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+// testIndex - an oci image index with linux amd64, arm64 and ppc64le instances
+func testIndex(t *testing.T) []byte {
+	idx := imgspecv1.Index{
+		MediaType: imgspecv1.MediaTypeImageIndex,
+		Manifests: []imgspecv1.Descriptor{
+			{MediaType: imgspecv1.MediaTypeImageManifest, Digest: amd64Digest, Size: 10, Platform: &imgspecv1.Platform{Architecture: "amd64", OS: "linux"}},
+			{MediaType: imgspecv1.MediaTypeImageManifest, Digest: arm64Digest, Size: 10, Platform: &imgspecv1.Platform{Architecture: "arm64", OS: "linux", Variant: "v8"}},
+			{MediaType: imgspecv1.MediaTypeImageManifest, Digest: ppc64leDigest, Size: 10, Platform: &imgspecv1.Platform{Architecture: "ppc64le", OS: "linux"}},
+		},
+	}
+	idx.SchemaVersion = 2
+	data, err := json.Marshal(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSelectInstances(t *testing.T) {
+	list := testIndex(t)
+
+	t.Run("Testing SelectInstances : should pass", func(t *testing.T) {
+		res, err := SelectInstances(list, imgspecv1.MediaTypeImageIndex, []string{"amd64", "arm64", "amd64"})
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 2 || res[0] != amd64Digest || res[1] != arm64Digest {
+			t.Fatalf("should select the amd64 and arm64 instances %v", res)
+		}
+	})
+
+	t.Run("Testing SelectInstances (not a list) : should pass", func(t *testing.T) {
+		res, err := SelectInstances([]byte("{}"), imgspecv1.MediaTypeImageManifest, []string{"amd64"})
+		if err != nil || res != nil {
+			t.Fatalf("should ignore single images %v %v", res, err)
+		}
+	})
+
+	t.Run("Testing SelectInstances (missing architecture) : should fail", func(t *testing.T) {
+		if _, err := SelectInstances(list, imgspecv1.MediaTypeImageIndex, []string{"s390x"}); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+}
+
+func TestTrimLayoutIndex(t *testing.T) {
+	dir := t.TempDir()
+	list := testIndex(t)
+	listDigest := digest.FromBytes(list)
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "blobs", "sha256", listDigest.Encoded()), list, 0644); err != nil {
+		t.Fatal(err)
+	}
+	layout := imgspecv1.Index{Manifests: []imgspecv1.Descriptor{
+		{MediaType: imgspecv1.MediaTypeImageIndex, Digest: listDigest, Size: int64(len(list)), Annotations: map[string]string{imgspecv1.AnnotationRefName: "v1"}},
+	}}
+	data, _ := json.Marshal(layout)
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := trimLayoutIndex(layoutDir("oci:"+dir+":v1"), list, list, []digest.Digest{arm64Digest})
+	if err != nil {
+		t.Fatalf("should not fail %v", err)
+	}
+
+	data, _ = os.ReadFile(filepath.Join(dir, "index.json"))
+	layout = imgspecv1.Index{}
+	if err := json.Unmarshal(data, &layout); err != nil {
+		t.Fatal(err)
+	}
+	top := layout.Manifests[0]
+	if top.Digest == listDigest || top.Annotations[imgspecv1.AnnotationRefName] != "v1" {
+		t.Fatalf("should point to the trimmed index %v", top)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "blobs", "sha256", top.Digest.Encoded()))
+	if err != nil || string(data) != string(res) {
+		t.Fatalf("should return the trimmed index %s %v", res, err)
+	}
+	var trimmed imgspecv1.Index
+	if err := json.Unmarshal(data, &trimmed); err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed.Manifests) != 1 || trimmed.Manifests[0].Digest != arm64Digest || int64(len(data)) != top.Size {
+		t.Fatalf("should keep only the arm64 instance %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blobs", "sha256", listDigest.Encoded())); err == nil {
+		t.Fatalf("should remove the original index blob")
+	}
+}
+
+func TestTrimList(t *testing.T) {
+	source := testIndex(t)
+
+	t.Run("Testing trimList (converted instances) : should pass", func(t *testing.T) {
+		// the copied instances of a converted list have new digests (same order as the source list)
+		converted := strings.Replace(string(source), arm64Digest.Encoded(), strings.Repeat("4", 64), 1)
+		res, err := trimList([]byte(converted), source, []digest.Digest{amd64Digest, arm64Digest})
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		var trimmed imgspecv1.Index
+		if err := json.Unmarshal(res, &trimmed); err != nil {
+			t.Fatal(err)
+		}
+		if len(trimmed.Manifests) != 2 || trimmed.Manifests[0].Digest != amd64Digest || trimmed.Manifests[1].Digest.Encoded() != strings.Repeat("4", 64) {
+			t.Fatalf("should keep the copied instances %s", res)
+		}
+		if trimmed.MediaType != imgspecv1.MediaTypeImageIndex || trimmed.SchemaVersion != 2 {
+			t.Fatalf("should keep the list fields %s", res)
+		}
+	})
+
+	t.Run("Testing trimList (not a list) : should pass", func(t *testing.T) {
+		res, err := trimList([]byte(`{"schemaVersion":2,"mediaType":"`+imgspecv1.MediaTypeImageManifest+`"}`), source, []digest.Digest{amd64Digest})
+		if err != nil || res != nil {
+			t.Fatalf("should ignore single images %s %v", res, err)
+		}
+	})
+
+	t.Run("Testing trimList (instances mismatch) : should fail", func(t *testing.T) {
+		if _, err := trimList(source, []byte(`{"manifests":[]}`), []digest.Digest{amd64Digest}); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+}
+
+func TestTrimDirManifest(t *testing.T) {
+	dir := t.TempDir()
+	list := testIndex(t)
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), list, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trimDirManifest(dir, list, list, []digest.Digest{ppc64leDigest}); err != nil {
+		t.Fatalf("should not fail %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trimmed imgspecv1.Index
+	if err := json.Unmarshal(data, &trimmed); err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed.Manifests) != 1 || trimmed.Manifests[0].Digest != ppc64leDigest {
+		t.Fatalf("should keep only the ppc64le instance %s", data)
+	}
+}
@@ -1,26 +1,27 @@
 package operator
 
 import (
-	"bufio"
 	"context"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
-	"time"
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/runlog"
 )
 
 const (
 	indexJson                   string = "index.json"
+	dirManifest                 string = "manifest.json"
+	ociLayout                   string = "oci-layout"
 	operatorImageExtractDir     string = "hold-operator"
 	workingDir                  string = "working-dir"
 	dockerProtocol              string = "docker://"
@@ -38,7 +39,7 @@ const (
 	diskToMirror                string = "diskToMirror"
 	mirrorToDisk                string = "mirrorToDisk"
 	errMsg                      string = "[OperatorImageCollector] %v "
-	logsFile                    string = "logs/operator.log"
+	logsFile                    string = "operator.log"
 )
 
 type CollectorInterface interface {
@@ -90,12 +91,11 @@ func (o *Collector) OperatorImageCollector(ctx context.Context) ([]v1alpha3.Copy
 
 	// check the mode
 	if o.Opts.Mode == mirrorToDisk {
-		f, err := os.Create(logsFile)
+		lf, err := runlog.Open(o.Log, o.Opts.Global.LogsDir, logsFile, o.Opts.Global.Quiet)
 		if err != nil {
 			o.Log.Error(errMsg, err)
 		}
-		writer := bufio.NewWriter(f)
-		defer f.Close()
+		defer lf.Close()
 		for _, op := range o.Config.Mirror.Operators {
 			// download the operator index image
 			o.Log.Info("copying operator image %v", op.Catalog)
@@ -110,19 +110,10 @@ func (o *Collector) OperatorImageCollector(ctx context.Context) ([]v1alpha3.Copy
 				}
 				src := dockerProtocol + op.Catalog
 				dest := ociProtocolTrimmed + dir
-				err = o.Mirror.Run(ctx, src, dest, "copy", &o.Opts, *writer)
-				writer.Flush()
+				err = o.Mirror.Run(ctx, src, dest, "copy", &o.Opts, *lf.Writer())
 				if err != nil {
 					o.Log.Error(errMsg, err)
 				}
-				// read the logs
-				f, _ := os.ReadFile(logsFile)
-				lines := strings.Split(string(f), "\n")
-				for _, s := range lines {
-					if len(s) > 0 {
-						o.Log.Debug("%s ", strings.ToLower(s))
-					}
-				}
 			}
 
 			// it's in oci format so we can go directly to the index.json file
@@ -199,67 +190,149 @@ func (o *Collector) OperatorImageCollector(ctx context.Context) ([]v1alpha3.Copy
 	}
 
 	if o.Opts.Mode == diskToMirror {
-		// check the directory to copy
-		regex, e := regexp.Compile(indexJson)
-		if e != nil {
-			o.Log.Error("%v", e)
+		operators := o.Config.Mirror.Operators
+		// discover the catalogs when none are set
+		if len(operators) == 0 && len(o.Opts.Global.OperatorsFrom) > 0 {
+			catalogs, err := DiskCatalogs(o.Opts.Global.OperatorsFrom)
+			if err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+			o.Log.Info("catalogs found on disk %v", catalogs)
+			for _, catalog := range catalogs {
+				operators = append(operators, v1alpha2.Operator{Catalog: dirProtocol + catalog})
+			}
 		}
-		for _, op := range o.Config.Mirror.Operators {
-			// Need to fix this - incase their are no operators in the ImageSetConfig
-			for _, pkg := range op.Packages {
-				imagesDir := strings.Replace(op.Catalog, "dir://", "", 1)
-				e = filepath.Walk(imagesDir, func(path string, info os.FileInfo, err error) error {
-					if err == nil && regex.MatchString(info.Name()) && strings.Contains(path, pkg.Name) {
-						o.Log.Info("path %s", filepath.Dir(path))
-						hld := strings.Split(filepath.Dir(path), operatorImageDir)
-						//ref := filepath.Dir(strings.Join(hld[1], "/"))
-						if len(hld) == 0 {
-							return fmt.Errorf(errMsg+"%s", "no directory found for operator-images ", path)
-						} else {
-							src := ociProtocolTrimmed + filepath.Dir(path)
-							dest := o.Opts.Destination + hld[1]
-							allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest})
-						}
-					}
+		for _, op := range operators {
+			// the related images are dir: images (oci layouts are also accepted)
+			// of the packages set, or all the images of the catalog when no packages are set
+			imagesDir := strings.TrimSuffix(strings.Replace(op.Catalog, dirProtocol, "", 1), "/")
+			e := filepath.Walk(imagesDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.IsDir() || filepath.Dir(path) == imagesDir || !includesPackage(path, op.Packages) {
+					return nil
+				}
+				var src string
+				switch info.Name() {
+				case indexJson:
+					src = ociProtocolTrimmed + filepath.Dir(path)
+				case dirManifest:
+					src = dirProtocolTrimmed + filepath.Dir(path)
+				default:
 					return nil
-				})
+				}
+				o.Log.Info("path %s", filepath.Dir(path))
+				// the images are cached in <catalog>/<bundle>/<path>/<tag> or <path>/sha256-<hash>
+				rel, err := filepath.Rel(imagesDir, filepath.Dir(path))
+				if err != nil {
+					return err
+				}
+				hld := strings.SplitN(rel, "/", 2)
+				if len(hld) < 2 {
+					return fmt.Errorf("no bundle directory found for %s", path)
+				}
+				dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeOperator, imageref.FromDir(hld[1]))
+				allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest, PreserveDigests: strings.Contains(dest, "@")})
+				return nil
+			})
+			if e != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, e)
 			}
-		}
-		if e != nil {
-			return []v1alpha3.CopyImageSchema{}, e
+			// the related images written to the blob store (mirrorToDisk --blob-store)
+			imgs, e := o.storeImages(imagesDir, op.Packages)
+			if e != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, e)
+			}
+			allImages = append(allImages, imgs...)
 		}
 	}
 	return allImages, nil
 }
 
-// customImageParser - simple image string parser
-func customImageParser(image string) (*v1alpha3.ImageRefSchema, error) {
-	var irs *v1alpha3.ImageRefSchema
-	var component string
-	parts := strings.Split(image, "/")
-	if len(parts) < 3 {
-		return irs, fmt.Errorf("[customImageParser] image url seems to be wrong %s ", image)
+// storeImages - returns the related images of the catalog dir in the blob store of its working dir
+// the ref names are the cache paths <catalog>/<bundle>/<path>/<tag> or <path>/sha256-<hash>
+func (o *Collector) storeImages(catalogDir string, packages []v1alpha2.IncludePackage) ([]v1alpha3.CopyImageSchema, error) {
+	workingDir, prefix, ok := blobstore.Split(catalogDir, operatorImageDir)
+	if !ok {
+		return nil, nil
+	}
+	images, err := blobstore.Images(workingDir, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var result []v1alpha3.CopyImageSchema
+	for _, img := range images {
+		hld := strings.SplitN(strings.TrimPrefix(img.Ref, prefix+"/"), "/", 2)
+		if len(hld) < 2 || !includesPackage(img.Ref, packages) {
+			continue
+		}
+		o.Log.Info("blob store image %s", img.Ref)
+		dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeOperator, imageref.FromDir(hld[1]))
+		result = append(result, v1alpha3.CopyImageSchema{Source: img.Source, Destination: dest, PreserveDigests: strings.Contains(dest, "@")})
+	}
+	return result, nil
+}
+
+// DiskCatalogs - returns the catalog (oci layout) directories in the operator-images directories under dir
+// i.e operator-images/<catalog>/<tag>
+func DiskCatalogs(dir string) ([]string, error) {
+	var catalogs []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == blobstore.StoreDir {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() || d.Name() != operatorImageDir {
+			return nil
+		}
+		return filepath.WalkDir(path, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if _, err := os.Stat(filepath.Join(path, ociLayout)); err == nil {
+				catalogs = append(catalogs, path)
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return catalogs, nil
+}
+
+// includesPackage - true if no packages are set or the image path contains a package name
+func includesPackage(path string, packages []v1alpha2.IncludePackage) bool {
+	if len(packages) == 0 {
+		return true
 	}
-	if strings.Contains(parts[2], "@") {
-		component = strings.Split(parts[2], "@")[0]
-	} else {
-		component = parts[2]
+	for _, pkg := range packages {
+		if strings.Contains(path, pkg.Name) {
+			return true
+		}
 	}
-	irs = &v1alpha3.ImageRefSchema{Repository: parts[0], Namespace: parts[1], Component: component}
-	return irs, nil
+	return false
 }
 
 // batchWorkerConverter convert RelatedImages to strings for batch worker
+// the images are cached as dir: images in <dir>/<bundle>/<path>/<tag> or <path>/sha256-<hash> (see imageref.Dir)
 func batchWorkerConverter(log clog.PluggableLoggerInterface, dir string, images map[string][]v1alpha3.RelatedImage) ([]v1alpha3.CopyImageSchema, error) {
 	var result []v1alpha3.CopyImageSchema
 	for bundle, relatedImgs := range images {
 		for _, img := range relatedImgs {
-			irs, err := customImageParser(img.Image)
+			irs, err := imageref.Parse(img.Image)
 			if err != nil {
 				log.Error("[batchWorkerConverter] %v", err)
 				return result, err
 			}
-			componentDir := strings.Join([]string{dir, bundle, irs.Namespace}, "/")
+			componentDir := strings.Join([]string{dir, bundle, imageref.Dir(irs)}, "/")
 			// do a lookup on dist first
 			if _, err := os.Stat(componentDir); errors.Is(err, os.ErrNotExist) {
 				err = os.MkdirAll(componentDir, 0755)
@@ -268,12 +341,7 @@ func batchWorkerConverter(log clog.PluggableLoggerInterface, dir string, images
 					return result, err
 				}
 				src := dockerProtocol + img.Image
-				if len(img.Name) == 0 {
-					timestamp := time.Now().Unix()
-					s := fmt.Sprintf("%d", timestamp)
-					img.Name = fmt.Sprintf("%x", sha256.Sum256([]byte(s)))[:6]
-				}
-				dest := dirProtocolTrimmed + strings.Join([]string{dir, bundle, irs.Namespace, img.Name}, "/")
+				dest := dirProtocolTrimmed + componentDir
 				log.Debug("source %s ", img.Image)
 				log.Debug("destination %s ", dest)
 				result = append(result, v1alpha3.CopyImageSchema{Source: src, Destination: dest})
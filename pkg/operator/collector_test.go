@@ -3,6 +3,8 @@ package operator
 import (
 	"bufio"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
@@ -158,6 +160,79 @@ func TestOperatorImageCollector(t *testing.T) {
 	// TODO: cover negative cases
 }
 
+func TestOperatorImageCollectorDiskToMirror(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	catalog := filepath.Join(dir, "mirror", operatorImageDir, "redhat-operator-index", "v4.12")
+	for file, data := range map[string]string{
+		ociLayout: `{"imageLayoutVersion": "1.0.0"}`,
+		indexJson: "{}",
+		"aws-load-balancer-operator.v0.2.0/openshift4/aws-load-balancer-rhel8-operator/sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea/" + dirManifest: "{}",
+		"aws-load-balancer-operator.v0.2.0/openshift4/aws-load-balancer-rhel8-operator/sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea/version":        "1.1",
+		"node-observability-operator.v0.1.0/noo/node-observability-agent/v0.1/" + indexJson:                                                                                    "{}",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(catalog, file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(catalog, file), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := mirror.CopyOptions{
+		Global:      &mirror.GlobalOptions{Dir: dir, OperatorsFrom: dir},
+		Destination: "docker://localhost:5000/test",
+		Mode:        diskToMirror,
+	}
+
+	t.Run("Testing DiskCatalogs : should pass", func(t *testing.T) {
+		catalogs, err := DiskCatalogs(dir)
+		if err != nil || len(catalogs) != 1 || catalogs[0] != catalog {
+			t.Fatalf("should find the catalog %v %v", catalogs, err)
+		}
+	})
+
+	t.Run("Testing OperatorImageCollector diskToMirror discover : should pass", func(t *testing.T) {
+		ex := &Collector{Log: log, Opts: opts, Manifest: &Manifest{Log: log}}
+		res, err := ex.OperatorImageCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("should return all the images of the catalog got %v", res)
+		}
+		if res[0].Source != "dir:"+filepath.Join(catalog, "aws-load-balancer-operator.v0.2.0/openshift4/aws-load-balancer-rhel8-operator/sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea") ||
+			res[0].Destination != "docker://localhost:5000/test/openshift4/aws-load-balancer-rhel8-operator@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea" || !res[0].PreserveDigests {
+			t.Fatalf("should push the dir: image by digest got %v", res[0])
+		}
+		if res[1].Source != "oci:"+filepath.Join(catalog, "node-observability-operator.v0.1.0/noo/node-observability-agent/v0.1") ||
+			res[1].Destination != "docker://localhost:5000/test/noo/node-observability-agent:v0.1" {
+			t.Fatalf("should push the oci layout with its tag got %v", res[1])
+		}
+	})
+
+	t.Run("Testing OperatorImageCollector diskToMirror packages : should pass", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Operators = []v1alpha2.Operator{{
+			Catalog:       "dir://" + catalog,
+			IncludeConfig: v1alpha2.IncludeConfig{Packages: []v1alpha2.IncludePackage{{Name: "aws-load-balancer-operator"}}},
+		}}
+		ex := &Collector{Log: log, Config: cfg, Opts: opts, Manifest: &Manifest{Log: log}}
+		res, err := ex.OperatorImageCollector(context.Background())
+		if err != nil || len(res) != 1 {
+			t.Fatalf("should only return the images of the package %v %v", res, err)
+		}
+	})
+
+	t.Run("Testing OperatorImageCollector diskToMirror missing catalog dir : should fail", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Operators = []v1alpha2.Operator{{Catalog: "dir://" + filepath.Join(dir, operatorImageDir, "missing")}}
+		ex := &Collector{Log: log, Config: cfg, Opts: opts, Manifest: &Manifest{Log: log}}
+		if res, err := ex.OperatorImageCollector(context.Background()); err == nil {
+			t.Fatalf("should fail on the walk error got %v", res)
+		}
+	})
+}
+
 // setup mocks
 // we need to mock Manifest, Mirror
 
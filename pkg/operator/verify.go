@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/verify"
+)
+
+// MissingImages - returns the related images of the catalogs in dir (selected as in the operators
+// config) that are not in the operator images cache (not a dir: image or an oci layout) or the blob store
+func MissingImages(m manifest.ManifestInterface, dir string, operators []v1alpha2.Operator) ([]string, error) {
+	compare := make(map[string]v1alpha3.ISCPackage)
+	for _, op := range operators {
+		for _, pkg := range op.Packages {
+			for _, channel := range pkg.Channels {
+				compare[pkg.Name] = v1alpha3.ISCPackage{Channel: channel.Name, MinVersion: channel.MinVersion, MaxVersion: channel.MaxVersion, Full: op.Full}
+			}
+		}
+	}
+
+	stored, err := blobstore.Refs(dir, operatorImageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, op := range operators {
+		hld := strings.Split(strings.TrimPrefix(op.Catalog, dockerProtocol), "/")
+		imageIndexDir := strings.Replace(hld[len(hld)-1], ":", "/", -1)
+		cacheDir := strings.Join([]string{dir, operatorImageExtractDir, imageIndexDir}, "/")
+		catalogDir := strings.Join([]string{dir, operatorImageDir, imageIndexDir}, "/")
+
+		label, err := catalogLabel(m, catalogDir)
+		if err != nil {
+			return nil, fmt.Errorf("catalog %s : %v", op.Catalog, err)
+		}
+		var relatedImages map[string][]v1alpha3.RelatedImage
+		if len(op.Packages) == 0 {
+			relatedImages, err = m.GetRelatedImagesFromCatalog(cacheDir, label)
+		} else {
+			relatedImages, err = m.GetRelatedImagesFromCatalogByFilter(cacheDir, label, op, compare)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("catalog %s : %v", op.Catalog, err)
+		}
+
+		// the images are cached in <catalog>/<bundle>/<path>/<tag or sha256-hash> (see batchWorkerConverter)
+		for bundle, imgs := range relatedImages {
+			for _, img := range imgs {
+				irs, err := imageref.Parse(img.Image)
+				if err != nil {
+					return nil, err
+				}
+				path := strings.Join([]string{catalogDir, bundle, imageref.Dir(irs)}, "/")
+				ref := filepath.ToSlash(filepath.Join(operatorImageDir, imageIndexDir, bundle, imageref.Dir(irs)))
+				if !verify.IsImage(path) && !stored[ref] {
+					missing = append(missing, path)
+				}
+			}
+		}
+	}
+	return missing, nil
+}
+
+// catalogLabel - reads the configs label of the catalog image (oci layout)
+func catalogLabel(m manifest.ManifestInterface, catalogDir string) (string, error) {
+	oci, err := m.GetImageIndex(catalogDir)
+	if err != nil {
+		return "", err
+	}
+	if len(oci.Manifests) == 0 || !strings.Contains(oci.Manifests[0].Digest, "sha256") {
+		return "", fmt.Errorf("no manifests found in %s", catalogDir)
+	}
+	manifestDir := strings.Join([]string{catalogDir, blobsDir, strings.Split(oci.Manifests[0].Digest, ":")[1]}, "/")
+	oci, err = m.GetImageManifest(manifestDir)
+	if err != nil {
+		return "", err
+	}
+	ocs, err := m.GetOperatorConfig(strings.Join([]string{catalogDir, blobsDir, strings.Split(oci.Config.Digest, ":")[1]}, "/"))
+	if err != nil {
+		return "", err
+	}
+	return ocs.Config.Labels.OperatorsOperatorframeworkIoIndexConfigsV1, nil
+}
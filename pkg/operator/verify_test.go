@@ -0,0 +1,40 @@
+package operator
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+)
+
+func TestMissingImages(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	catalogDir := dir + "/" + operatorImageDir + "/redhat-operator-index/v4.14"
+	digestDir := "sha256-f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"
+	// sometestimage-a is a dir: image, sometestimage-b an empty dir (an interrupted copy)
+	imageA := catalogDir + "/abc/name/sometestimage-a/" + digestDir
+	for _, d := range []string{imageA, catalogDir + "/abc/name/sometestimage-b/" + digestDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{dirManifest, "version"} {
+		if err := os.WriteFile(imageA+"/"+file, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operators := []v1alpha2.Operator{{Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.14"}}
+
+	t.Run("Testing MissingImages : should pass", func(t *testing.T) {
+		missing, err := MissingImages(&Manifest{Log: log}, dir, operators)
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(missing) != 1 || !strings.HasSuffix(missing[0], "/abc/name/sometestimage-b/"+digestDir) {
+			t.Fatalf("should report sometestimage-b %v", missing)
+		}
+	})
+}
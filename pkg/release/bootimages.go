@@ -0,0 +1,92 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	coreosBootImagesManifest string = "0000_50_installer_coreos-bootimages.yaml"
+	coreosStreamKey          string = "stream"
+	coreosStreamFile         string = "coreos-stream.json"
+	coreosArtifactsFile      string = "coreos-artifacts.txt"
+)
+
+// bootImagesConfigMap - the fields used from the coreos-bootimages ConfigMap
+type bootImagesConfigMap struct {
+	Data map[string]string `json:"data"`
+}
+
+// saveBootImages - saves the coreos stream metadata of an extracted release (cacheDir)
+// to the release directory (dir), the artifact URLs are optionally listed
+func saveBootImages(cacheDir, dir string, cfg v1alpha2.BootImages) error {
+	data, err := os.ReadFile(strings.Join([]string{cacheDir, releaseManifests, coreosBootImagesManifest}, "/"))
+	if err != nil {
+		return err
+	}
+	var cm bootImagesConfigMap
+	err = yaml.Unmarshal(data, &cm)
+	if err != nil {
+		return err
+	}
+	streamData, ok := cm.Data[coreosStreamKey]
+	if !ok {
+		return fmt.Errorf("%s has no %s key", coreosBootImagesManifest, coreosStreamKey)
+	}
+	var stream v1alpha3.CoreOSStream
+	err = json.Unmarshal([]byte(streamData), &stream)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(dir+"/"+coreosStreamFile, []byte(streamData), 0644)
+	if err != nil {
+		return err
+	}
+	if !cfg.ListArtifacts {
+		return nil
+	}
+	artifacts := bootImageArtifacts(stream, cfg.Formats)
+	return os.WriteFile(dir+"/"+coreosArtifactsFile, []byte(strings.Join(artifacts, "\n")+"\n"), 0644)
+}
+
+// bootImageArtifacts - returns a sorted list of artifacts in the format
+// <arch>/<platform>/<format>/<type> <location> <sha256>
+// formats filters on the format name (an empty list returns all formats)
+func bootImageArtifacts(stream v1alpha3.CoreOSStream, formats []string) []string {
+	var result []string
+	for arch, a := range stream.Architectures {
+		for platform, p := range a.Artifacts {
+			for format, f := range p.Formats {
+				if !matchFormat(format, formats) {
+					continue
+				}
+				for name, artifact := range f {
+					id := strings.Join([]string{arch, platform, format, name}, "/")
+					result = append(result, fmt.Sprintf("%s %s %s", id, artifact.Location, artifact.Sha256))
+				}
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// matchFormat - true if the format starts with one of the filters (i.e. qcow2 matches qcow2.gz)
+func matchFormat(format string, formats []string) bool {
+	if len(formats) == 0 {
+		return true
+	}
+	for _, f := range formats {
+		if strings.HasPrefix(format, f) {
+			return true
+		}
+	}
+	return false
+}
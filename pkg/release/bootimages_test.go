@@ -0,0 +1,100 @@
+package release
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+)
+
+const testBootImages = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: coreos-bootimages
+  namespace: openshift-machine-config-operator
+data:
+  releaseVersion: 0.0.1-snapshot
+  stream: |
+    {
+      "stream": "rhcos-4.12",
+      "architectures": {
+        "x86_64": {
+          "artifacts": {
+            "metal": {
+              "release": "412.86.202212081411-0",
+              "formats": {
+                "iso": {
+                  "disk": {"location": "https://example.com/rhcos-live.x86_64.iso", "sha256": "aaa"}
+                },
+                "pxe": {
+                  "kernel": {"location": "https://example.com/rhcos-live-kernel-x86_64", "sha256": "bbb"},
+                  "rootfs": {"location": "https://example.com/rhcos-live-rootfs.x86_64.img", "sha256": "ccc"}
+                }
+              }
+            },
+            "qemu": {
+              "release": "412.86.202212081411-0",
+              "formats": {
+                "qcow2.gz": {
+                  "disk": {"location": "https://example.com/rhcos-qemu.x86_64.qcow2.gz", "sha256": "ddd"}
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+`
+
+func TestSaveBootImages(t *testing.T) {
+
+	setup := func(t *testing.T) (string, string) {
+		cacheDir := t.TempDir()
+		dir := t.TempDir()
+		if err := os.MkdirAll(cacheDir+"/"+releaseManifests, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(strings.Join([]string{cacheDir, releaseManifests, coreosBootImagesManifest}, "/"), []byte(testBootImages), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return cacheDir, dir
+	}
+
+	t.Run("Testing saveBootImages : should pass", func(t *testing.T) {
+		cacheDir, dir := setup(t)
+		err := saveBootImages(cacheDir, dir, v1alpha2.BootImages{})
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if _, err := os.Stat(dir + "/" + coreosStreamFile); err != nil {
+			t.Fatalf("should save the stream %v", err)
+		}
+		if _, err := os.Stat(dir + "/" + coreosArtifactsFile); err == nil {
+			t.Fatalf("should not list the artifacts")
+		}
+	})
+
+	t.Run("Testing saveBootImages with artifacts : should pass", func(t *testing.T) {
+		cacheDir, dir := setup(t)
+		err := saveBootImages(cacheDir, dir, v1alpha2.BootImages{ListArtifacts: true, Formats: []string{"iso", "qcow2"}})
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		data, err := os.ReadFile(dir + "/" + coreosArtifactsFile)
+		if err != nil {
+			t.Fatalf("should list the artifacts %v", err)
+		}
+		expected := "x86_64/metal/iso/disk https://example.com/rhcos-live.x86_64.iso aaa\n" +
+			"x86_64/qemu/qcow2.gz/disk https://example.com/rhcos-qemu.x86_64.qcow2.gz ddd\n"
+		if string(data) != expected {
+			t.Fatalf("should list the filtered artifacts got %s", data)
+		}
+	})
+
+	t.Run("Testing saveBootImages no manifest : should fail", func(t *testing.T) {
+		if err := saveBootImages(t.TempDir(), t.TempDir(), v1alpha2.BootImages{}); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+}
@@ -234,6 +234,25 @@ func (o *CincinnatiSchema) GetReleaseReferenceImages(ctx context.Context) []v1al
 			}
 			allImages = append(allImages, newDownloads...)
 		}
+
+		// exact release versions are resolved through cincinnati
+		for _, rel := range o.Config.Mirror.Platform.Releases {
+			if len(rel.Version) == 0 {
+				continue
+			}
+			client, err := o.NewOCPClient(o.Opts.UUID)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			download, err := getReleaseVersionDownload(ctx, client, arch, rel)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			o.Log.Info("found release %s %s", rel.Version, download.Source)
+			allImages = append(allImages, download)
+		}
 	}
 
 	imgs, err := o.Signature.GenerateReleaseSignatures(ctx, allImages)
@@ -241,6 +260,15 @@ func (o *CincinnatiSchema) GetReleaseReferenceImages(ctx context.Context) []v1al
 		o.Log.Error("error list %v ", err)
 	}
 
+	// explicit release payload references (ci or hotfix builds)
+	// are not published with release signatures
+	for _, rel := range o.Config.Mirror.Platform.Releases {
+		if len(rel.Image) > 0 {
+			o.Log.Warn("release payload %s is not signature verified", rel.Image)
+			imgs = append(imgs, v1alpha3.CopyImageSchema{Source: strings.TrimPrefix(rel.Image, dockerProtocol), Destination: ""})
+		}
+	}
+
 	for _, e := range errs {
 		o.Log.Error("error list %v ", e)
 	}
@@ -269,7 +297,14 @@ func getChannelDownloads(ctx context.Context, log clog.PluggableLoggerInterface,
 	}
 
 	var newDownloads []v1alpha3.CopyImageSchema
-	if channel.ShortestPath {
+	if channel.EUS {
+		current, newest, updates, err := CalculateEUSUpgrades(ctx, c, arch, channel.Name, first, last)
+		if err != nil {
+			return allImages, err
+		}
+		newDownloads = gatherUpdates(log, current, newest, updates)
+
+	} else if channel.ShortestPath {
 		current, newest, updates, err := CalculateUpgrades(ctx, c, arch, channel.Name, channel.Name, first, last)
 		if err != nil {
 			return allImages, err
@@ -326,6 +361,23 @@ func getCrossChannelDownloads(ctx context.Context, log clog.PluggableLoggerInter
 	return gatherUpdates(log, current, newest, updates), nil
 }
 
+// getReleaseVersionDownload will resolve an exact release version through cincinnati
+func getReleaseVersionDownload(ctx context.Context, c Client, arch string, rel v1alpha2.Release) (v1alpha3.CopyImageSchema, error) {
+	version, err := semver.Parse(rel.Version)
+	if err != nil {
+		return v1alpha3.CopyImageSchema{}, err
+	}
+	channel := rel.Channel
+	if len(channel) == 0 {
+		channel = fmt.Sprintf("stable-%d.%d", version.Major, version.Minor)
+	}
+	_, requested, _, err := GetUpdates(ctx, c, arch, channel, version, version)
+	if err != nil {
+		return v1alpha3.CopyImageSchema{}, err
+	}
+	return v1alpha3.CopyImageSchema{Source: requested.Image, Destination: ""}, nil
+}
+
 // gatherUpdates
 func gatherUpdates(log clog.PluggableLoggerInterface, current, newest Update, updates []Update) []v1alpha3.CopyImageSchema {
 	var allImages []v1alpha3.CopyImageSchema
@@ -119,6 +119,65 @@ func TestGetReleaseReferenceImages(t *testing.T) {
 	})
 }
 
+func TestGetReleaseReferenceImagesExplicit(t *testing.T) {
+
+	log := clog.New("trace")
+
+	global := &mirror.GlobalOptions{TlsVerify: false, InsecurePolicy: true}
+	opts := mirror.CopyOptions{
+		Global:      global,
+		Destination: "oci:test",
+		Mode:        mirrorToDisk,
+	}
+
+	cfg := v1alpha2.ImageSetConfiguration{
+		ImageSetConfigurationSpec: v1alpha2.ImageSetConfigurationSpec{
+			Mirror: v1alpha2.Mirror{
+				Platform: v1alpha2.Platform{
+					Architectures: []string{"amd64"},
+					Releases: []v1alpha2.Release{
+						{Version: "4.2.0-3"},
+						{Image: "quay.io/openshift-release-dev/ocp-release@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("TestGetReleaseReferenceImages explicit releases should pass", func(t *testing.T) {
+		c := &mockClient{}
+		requestQuery := make(chan string, 1)
+		defer close(requestQuery)
+
+		handler := getHandlerMulti(t, requestQuery)
+		ts := httptest.NewServer(http.HandlerFunc(handler))
+		t.Cleanup(ts.Close)
+
+		endpoint, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("should not fail endpoint parse")
+		}
+		c.url = endpoint
+		signature := &mockSignature{Log: log}
+		sch := NewCincinnati(log, &cfg, &opts, c, false, signature)
+		res := sch.GetReleaseReferenceImages(context.Background())
+		if len(res) != 1 {
+			t.Fatalf("should return the explicit release image")
+		}
+		if res[0].Source != cfg.Mirror.Platform.Releases[1].Image {
+			t.Fatalf("should return the explicit release image got %s", res[0].Source)
+		}
+
+		download, err := getReleaseVersionDownload(context.Background(), c, "amd64", cfg.Mirror.Platform.Releases[0])
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if download.Source != "quay.io/openshift-release-dev/ocp-release:4.2.0-3" {
+			t.Fatalf("should resolve the release version got %s", download.Source)
+		}
+	})
+}
+
 type mockSignature struct {
 	Log clog.PluggableLoggerInterface
 }
@@ -1,24 +1,29 @@
 package release
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/imageref"
 	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
 	"github.com/lmzuccarelli/golang-fb-mirror/pkg/mirror"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/runlog"
 )
 
 const (
-	indexJson                   string = "manifest.json"
+	dirManifest                 string = "manifest.json"
+	dirVersion                  string = "version"
 	operatorImageExtractDir     string = "hold-operator"
 	workingDir                  string = "working-dir"
 	dockerProtocol              string = "docker://"
@@ -37,7 +42,12 @@ const (
 	errMsg                      string = "[ReleaseImageCollector] %v "
 	diskToMirror                string = "diskToMirror"
 	mirrorToDisk                string = "mirrorToDisk"
-	logFile                     string = "logs/release.log"
+	logFile                     string = "release.log"
+	multiArch                   string = "multi"
+	releaseImagesRepo           string = "openshift/release-images"
+	releaseComponentsRepo       string = "openshift/release"
+	ociImageIndex               string = "application/vnd.oci.image.index.v1+json"
+	dockerManifestList          string = "application/vnd.docker.distribution.manifest.list.v2+json"
 )
 
 type CollectorInterface interface {
@@ -74,16 +84,13 @@ func (o *Collector) ReleaseImageCollector(ctx context.Context) ([]v1alpha3.CopyI
 
 	if o.Opts.Mode == mirrorToDisk {
 		releases := o.Cincinnati.GetReleaseReferenceImages(ctx)
-		f, err := os.Create(logFile)
+		lf, err := runlog.Open(o.Log, o.Opts.Global.LogsDir, logFile, o.Opts.Global.Quiet)
 		if err != nil {
 			o.Log.Error("[ReleaseImageCollector] %v", err)
 		}
-
-		writer := bufio.NewWriter(f)
-		defer f.Close()
+		defer lf.Close()
 		for _, value := range releases {
-			hld := strings.Split(value.Source, "/")
-			imageIndexDir = strings.Replace(hld[len(hld)-1], ":", "/", -1)
+			imageIndexDir = releaseIndexDir(value.Source)
 			cacheDir := strings.Join([]string{o.Opts.Global.Dir, releaseImageExtractDir, imageIndexDir}, "/")
 			dir := strings.Join([]string{o.Opts.Global.Dir, releaseImageDir, imageIndexDir}, "/")
 			if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
@@ -94,20 +101,18 @@ func (o *Collector) ReleaseImageCollector(ctx context.Context) ([]v1alpha3.CopyI
 				}
 				src := dockerProtocol + value.Source
 				dest := ociProtocolTrimmed + dir
-				err = o.Mirror.Run(ctx, src, dest, "copy", &o.Opts, *writer)
+				copyOpts := o.Opts
+				if o.isMultiArch(value.Source) {
+					// keep the manifest list (all architectures) intact
+					copyOpts.All = true
+					copyOpts.MultiArch = ""
+					copyOpts.PreserveDigests = true
+				}
+				err = o.Mirror.Run(ctx, src, dest, "copy", &copyOpts, *lf.Writer())
 				if err != nil {
 					return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
 				}
 				o.Log.Debug("copied release index image %s ", value.Source)
-
-				// TODO: create common function to show logs
-				f, _ := os.ReadFile(logFile)
-				lines := strings.Split(string(f), "\n")
-				for _, s := range lines {
-					if len(s) > 0 {
-						o.Log.Debug(" %s ", strings.ToLower(s))
-					}
-				}
 			} else {
 				o.Log.Info("cache release-index directory exists %s", cacheDir)
 			}
@@ -122,91 +127,352 @@ func (o *Collector) ReleaseImageCollector(ctx context.Context) ([]v1alpha3.CopyI
 			if len(oci.Manifests) == 0 {
 				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, "image index not found ")
 			}
-			manifest := strings.Split(oci.Manifests[0].Digest, ":")[1]
-			o.Log.Debug("image index %v", manifest)
-
-			manifestDir := strings.Join([]string{dir, blobsDir, manifest}, "/")
-			mfst, err := o.Manifest.GetImageManifest(manifestDir)
+			manifests, err := o.resolveReleaseManifests(dir, oci.Manifests[0])
 			if err != nil {
 				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
 			}
-			o.Log.Debug("manifest %v ", oci.Config.Digest)
 
 			fromDir := strings.Join([]string{dir, blobsDir}, "/")
-			err = o.Manifest.ExtractLayersOCI(fromDir, cacheDir, releaseManifests, mfst)
+			for i, m := range manifests {
+				manifest := strings.Split(m.Digest, ":")[1]
+				o.Log.Debug("image index %v", manifest)
+
+				manifestDir := strings.Join([]string{dir, blobsDir, manifest}, "/")
+				mfst, err := o.Manifest.GetImageManifest(manifestDir)
+				if err != nil {
+					return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+				}
+				o.Log.Debug("manifest %v ", mfst.Config.Digest)
+
+				// the first (or only) architecture is extracted to the cache directory
+				// any other architectures are extracted to cacheDir/<arch>
+				toDir := cacheDir
+				if i > 0 && m.Platform != nil {
+					toDir = strings.Join([]string{cacheDir, m.Platform.Architecture}, "/")
+				}
+				err = o.Manifest.ExtractLayersOCI(fromDir, toDir, releaseManifests, mfst)
+				if err != nil {
+					return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+				}
+				o.Log.Debug("extracted layer %s ", toDir)
+			}
+
+			allRelatedImages, err := o.releaseRelatedImages(cacheDir)
 			if err != nil {
 				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
 			}
-			o.Log.Debug("extracted layer %s ", cacheDir)
 
-			// overkill but its used for consistency
-			releaseDir := strings.Join([]string{cacheDir, releaseImageExtractFullPath}, "/")
-			allRelatedImages, err := o.Manifest.GetReleaseSchema(releaseDir)
+			// older releases do not ship the coreos-bootimages manifest
+			err = saveBootImages(cacheDir, dir, o.Config.Mirror.Platform.BootImages)
 			if err != nil {
-				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+				o.Log.Warn("coreos boot images stream metadata not saved for %s : %v", value.Source, err)
 			}
 
-			tmpImages, err := batcWorkerConverter(o.Log, dir, allRelatedImages)
+			tmpImages, err := batcWorkerConverter(o.Log, dir, allRelatedImages, o.Config.Mirror.Platform.Components)
 			if err != nil {
 				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
 			}
+			if o.isMultiArch(value.Source) {
+				for i := range tmpImages {
+					tmpImages[i].MultiArch = "all"
+				}
+			}
 
 			// need to append images
 			allImages = append(allImages, tmpImages...)
 		}
 	}
 	if o.Opts.Mode == diskToMirror {
-		// we know the directory format is
-		// release-images/name/version/
-		// we can do some replacing from the directory passed as string
-		// so tha twe can access the image-references
-		str := strings.Replace(o.Config.Mirror.Platform.Release, "release-images", "hold-release", 1)
-		// remove the file prefix
-		str = strings.Replace(str, "dir://", "", 1)
-
-		// get all release images from manifest (json)
-		allRelatedImages, err := o.Manifest.GetReleaseSchema(str + "/" + releaseImageExtractFullPath)
+		releases, err := o.diskReleases()
 		if err != nil {
 			return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
 		}
+		for _, release := range releases {
+			tmpImages, err := o.diskReleaseImages(release)
+			if err != nil {
+				return []v1alpha3.CopyImageSchema{}, fmt.Errorf(errMsg, err)
+			}
+			allImages = append(allImages, tmpImages...)
+		}
+	}
+	return allImages, nil
+}
 
-		// set up a regex for the manifest.json (checked in each directory)
-		regex, e := regexp.Compile(indexJson)
-		if e != nil {
-			o.Log.Error("%v", e)
-		}
-
-		// walk through the directory structure to look for manifest.json files
-		// get the base directory and do a lookup on the actual image to mirror
-		imagesDir := strings.Replace(o.Config.Mirror.Platform.Release, "dir://", "", 1)
-		imagesDir = imagesDir + "/images"
-		errFP := filepath.Walk(imagesDir, func(path string, info os.FileInfo, err error) error {
-			if err == nil && regex.MatchString(info.Name()) {
-				component := strings.Split(filepath.Dir(path), "/")
-				img := findRelatedImage(component[len(component)-1], allRelatedImages)
-				if len(img) > 0 {
-					src := dirProtocolTrimmed + filepath.Dir(path)
-					dest := o.Opts.Destination + "/" + img
-					allImages = append(allImages, v1alpha3.CopyImageSchema{Source: src, Destination: dest})
-				} else {
-					o.Log.Warn("component not found %s", component[len(component)-1])
-				}
-			} else if err != nil {
-				return err
+// diskReleases - returns the release-images/<name>/<version> directories to mirror
+// the release (or list of releases) from the config is used if set,
+// otherwise all releases under the working dir (or the release from dir) are discovered
+func (o *Collector) diskReleases() ([]string, error) {
+	var releases []string
+	if len(o.Config.Mirror.Platform.Release) > 0 {
+		releases = append(releases, o.Config.Mirror.Platform.Release)
+	}
+	releases = append(releases, o.Config.Mirror.Platform.ReleaseDirs...)
+	if len(releases) > 0 {
+		for i := range releases {
+			releases[i] = strings.TrimSuffix(strings.Replace(releases[i], dirProtocol, "", 1), "/")
+		}
+		return releases, nil
+	}
+
+	dir := o.Opts.Global.Dir
+	if len(o.Opts.Global.ReleaseFrom) > 0 {
+		dir = o.Opts.Global.ReleaseFrom
+	}
+	releases, err := DiskReleases(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		// the working dir may only have other content
+		if len(o.Config.Mirror.Operators) > 0 || len(o.Config.Mirror.AdditionalImages) > 0 || len(o.Opts.Global.ReleaseFrom) > 0 {
+			o.Log.Info("no releases found in %s", dir)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("no releases found in %s", dir)
+	}
+	o.Log.Info("releases found on disk %v", releases)
+	return releases, nil
+}
+
+// DiskReleases - returns the release-images/<name>/<version> directories of dir
+// (diskToMirror working-dir) or of a mirrorToDisk destination in dir (working-dir/<dest>)
+func DiskReleases(dir string) ([]string, error) {
+	var releases []string
+	for _, pattern := range []string{
+		strings.Join([]string{dir, releaseImageDir, "*", "*"}, "/"),
+		strings.Join([]string{dir, "*", releaseImageDir, "*", "*"}, "/"),
+	} {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			// the release layouts only, not a blob store per image type (blob-store/release-images)
+			if rel, _ := filepath.Rel(dir, path); strings.HasPrefix(rel, blobstore.StoreDir+"/") {
+				continue
+			}
+			if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+				releases = append(releases, path)
 			}
-			return nil
-		})
-		if errFP != nil {
-			return []v1alpha3.CopyImageSchema{}, e
+		}
+	}
+	return releases, nil
+}
+
+// diskReleaseImages - returns the release image (version tagged) and the components
+// of a release-images/<name>/<version> directory mapped with its own image-references
+func (o *Collector) diskReleaseImages(release string) ([]v1alpha3.CopyImageSchema, error) {
+	var allImages []v1alpha3.CopyImageSchema
+	// we know the directory format is
+	// release-images/name/version/
+	// we can do some replacing from the directory passed as string
+	// so that we can access the image-references
+	str := strings.Replace(release, releaseImageDir, releaseImageExtractDir, 1)
+
+	// get all release images from manifest (json)
+	allRelatedImages, err := o.releaseRelatedImages(str)
+	if err != nil {
+		return []v1alpha3.CopyImageSchema{}, err
+	}
+
+	// the release image itself is tagged with its version and arch
+	releaseImage := v1alpha3.CopyImageSchema{
+		Source:          ociProtocolTrimmed + release,
+		Destination:     o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeRelease, releaseImagesRepo+":"+o.releaseImageTag(release, str)),
+		PreserveDigests: true,
+	}
+	if o.isMultiArch(release) {
+		releaseImage.MultiArch = "all"
+	}
+	allImages = append(allImages, releaseImage)
+
+	// the component images are mapped with the image-references (by name)
+	addComponent := func(name, src string) {
+		img := findRelatedImage(name, allRelatedImages)
+		if !componentIncluded(name, o.Config.Mirror.Platform.Components) {
+			o.Log.Debug("component excluded %s", name)
+		} else if len(img) > 0 {
+			dest := o.Opts.Destination + "/" + imageref.DestinationPath(o.Config.Mirror.DestinationRules, imageref.TypeRelease, componentDestination(name, img))
+			copyImage := v1alpha3.CopyImageSchema{Source: src, Destination: dest, PreserveDigests: true}
+			if o.isMultiArch(release) {
+				copyImage.MultiArch = "all"
+			}
+			allImages = append(allImages, copyImage)
+		} else {
+			o.Log.Warn("component not found %s", name)
+		}
+	}
+
+	// set up a regex for the manifest.json (checked in each directory)
+	regex, err := regexp.Compile(dirManifest)
+	if err != nil {
+		return []v1alpha3.CopyImageSchema{}, err
+	}
+
+	// walk through the directory structure to look for manifest.json files
+	// get the base directory and do a lookup on the actual image to mirror
+	// (the images dir does not exist when all the components are in the blob store)
+	imagesDir := release + "/images"
+	err = filepath.Walk(imagesDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && regex.MatchString(info.Name()) {
+			addComponent(filepath.Base(filepath.Dir(path)), dirProtocolTrimmed+filepath.Dir(path))
+		} else if err != nil && !(path == imagesDir && errors.Is(err, os.ErrNotExist)) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return []v1alpha3.CopyImageSchema{}, err
+	}
+
+	// the components written to the blob store (mirrorToDisk --blob-store)
+	if workingDir, prefix, ok := blobstore.Split(imagesDir, releaseImageDir); ok {
+		images, err := blobstore.Images(workingDir, prefix)
+		if err != nil {
+			return []v1alpha3.CopyImageSchema{}, err
+		}
+		for _, img := range images {
+			addComponent(path.Base(img.Ref), img.Source)
 		}
 	}
 	return allImages, nil
 }
 
+// releaseImageTag - returns the <version>-<arch> tag for a release directory
+// digest pinned releases (sha256-<hash>) use the version from the image-references
+func (o *Collector) releaseImageTag(release, holdDir string) string {
+	version := filepath.Base(release)
+	if !strings.HasPrefix(version, "sha256-") {
+		return version
+	}
+	var schema v1alpha3.ReleaseSchema
+	data, err := os.ReadFile(holdDir + "/" + releaseImageExtractFullPath)
+	if err != nil || json.Unmarshal(data, &schema) != nil || len(schema.Metadata.Name) == 0 {
+		o.Log.Warn("release version not found for %s", release)
+		return version
+	}
+	return schema.Metadata.Name + "-" + releaseArch(o.Config.Mirror.Platform.Architectures)
+}
+
+// releaseArch - converts the (first) configured architecture to the release naming
+func releaseArch(architectures []string) string {
+	if len(architectures) == 0 {
+		return "x86_64"
+	}
+	switch architectures[0] {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	default:
+		return architectures[0]
+	}
+}
+
+// componentDestination - returns the repository (openshift/release) and digest for a component
+// i.e. openshift-release-dev/ocp-v4.0-art-dev@sha256:<hash> -> openshift/release@sha256:<hash>
+func componentDestination(name, img string) string {
+	if i := strings.Index(img, "@"); i >= 0 {
+		return releaseComponentsRepo + img[i:]
+	}
+	return releaseComponentsRepo + ":" + name
+}
+
+// isMultiArch - true if the multi architecture (manifest list) payload is mirrored
+// release is the release image or directory (i.e. ocp-release:4.12.0-multi)
+func (o *Collector) isMultiArch(release string) bool {
+	for _, arch := range o.Config.Mirror.Platform.Architectures {
+		if arch == multiArch {
+			return true
+		}
+	}
+	return strings.HasSuffix(strings.TrimSuffix(release, "/"), "-"+multiArch)
+}
+
+// resolveReleaseManifests - returns the manifest(s) to extract for a release
+// an image index (manifest list) is resolved to the manifest for the configured
+// architecture, or to every manifest in the list for the multi payload
+func (o *Collector) resolveReleaseManifests(dir string, m v1alpha3.OCIManifest) ([]v1alpha3.OCIManifest, error) {
+	if m.MediaType != ociImageIndex && m.MediaType != dockerManifestList {
+		return []v1alpha3.OCIManifest{m}, nil
+	}
+	list, err := o.Manifest.GetImageManifest(strings.Join([]string{dir, blobsDir, strings.Split(m.Digest, ":")[1]}, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Manifests) == 0 {
+		return nil, fmt.Errorf("manifest list %s is empty", m.Digest)
+	}
+	if o.isMultiArch(dir) {
+		return list.Manifests, nil
+	}
+	for _, arch := range o.Config.Mirror.Platform.Architectures {
+		for _, lm := range list.Manifests {
+			if lm.Platform != nil && lm.Platform.Architecture == arch {
+				return []v1alpha3.OCIManifest{lm}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no manifest found in list %s for architectures %v", m.Digest, o.Config.Mirror.Platform.Architectures)
+}
+
+// releaseRelatedImages - reads the image-references of an extracted release
+// for the multi payload the image-references of each architecture are unioned
+func (o *Collector) releaseRelatedImages(cacheDir string) ([]v1alpha3.RelatedImage, error) {
+	allRelatedImages, err := o.Manifest.GetReleaseSchema(strings.Join([]string{cacheDir, releaseImageExtractFullPath}, "/"))
+	if err != nil {
+		return nil, err
+	}
+	dirs, _ := os.ReadDir(cacheDir)
+	for _, d := range dirs {
+		if !d.IsDir() || d.Name() == releaseManifests {
+			continue
+		}
+		file := strings.Join([]string{cacheDir, d.Name(), releaseImageExtractFullPath}, "/")
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		archImages, err := o.Manifest.GetReleaseSchema(file)
+		if err != nil {
+			return nil, err
+		}
+		allRelatedImages = unionRelatedImages(allRelatedImages, archImages, d.Name())
+	}
+	return allRelatedImages, nil
+}
+
+// unionRelatedImages - adds the images not yet referenced
+// a component name referencing a different image is suffixed with the architecture
+func unionRelatedImages(images, add []v1alpha3.RelatedImage, arch string) []v1alpha3.RelatedImage {
+	names := make(map[string]string)
+	for _, img := range images {
+		names[img.Name] = img.Image
+	}
+	for _, img := range add {
+		existing, ok := names[img.Name]
+		if ok && existing == img.Image {
+			continue
+		}
+		if ok {
+			img.Name = img.Name + "-" + arch
+			if _, dup := names[img.Name]; dup {
+				continue
+			}
+		}
+		names[img.Name] = img.Image
+		images = append(images, img)
+	}
+	return images
+}
+
 // batchWorkerConverter convert RelatedImages to strings for batch worker
-func batcWorkerConverter(log clog.PluggableLoggerInterface, dir string, images []v1alpha3.RelatedImage) ([]v1alpha3.CopyImageSchema, error) {
+func batcWorkerConverter(log clog.PluggableLoggerInterface, dir string, images []v1alpha3.RelatedImage, filter v1alpha2.ComponentFilter) ([]v1alpha3.CopyImageSchema, error) {
 	var result []v1alpha3.CopyImageSchema
 	for _, img := range images {
+		if !componentIncluded(img.Name, filter) {
+			log.Debug("component excluded %s ", img.Name)
+			continue
+		}
 		src := dockerProtocol + img.Image
 		dest := dirProtocolTrimmed + strings.Join([]string{dir, "images", img.Name}, "/")
 		// do a lookup on dist first
@@ -227,6 +493,36 @@ func batcWorkerConverter(log clog.PluggableLoggerInterface, dir string, images [
 	return result, nil
 }
 
+// releaseIndexDir - returns the name/version directory for a release image
+// digest pinned images use name/sha256-<hash>
+func releaseIndexDir(image string) string {
+	hld := strings.Split(image, "/")
+	name := hld[len(hld)-1]
+	if strings.Contains(name, "@") {
+		return strings.Replace(strings.Replace(name, "@", "/", 1), ":", "-", 1)
+	}
+	return strings.Replace(name, ":", "/", -1)
+}
+
+// componentIncluded - applies the include and exclude patterns to a release component name
+// with no include patterns all components are included
+func componentIncluded(name string, filter v1alpha2.ComponentFilter) bool {
+	for _, pattern := range filter.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(filter.Include) == 0 {
+		return true
+	}
+	for _, pattern := range filter.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // findRelatedImage
 func findRelatedImage(name string, imgs []v1alpha3.RelatedImage) string {
 	for _, img := range imgs {
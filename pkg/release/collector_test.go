@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -213,6 +214,241 @@ func TestReleaseImageCollector(t *testing.T) {
 	})
 }
 
+func TestReleaseImageCollectorDiskToMirror(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	for _, release := range []string{"ocp-release/4.12.3-x86_64", "ocp-release/4.12.7-x86_64"} {
+		for _, component := range []string{"testA", "testB"} {
+			componentDir := strings.Join([]string{dir, "test-lmz", releaseImageDir, release, "images", component}, "/")
+			if err := os.MkdirAll(componentDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(componentDir+"/"+dirManifest, []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	opts := mirror.CopyOptions{
+		Global:      &mirror.GlobalOptions{Dir: dir},
+		Destination: "docker://localhost:5000/test",
+		Mode:        diskToMirror,
+	}
+
+	t.Run("Testing ReleaseImageCollector diskToMirror discover : should pass", func(t *testing.T) {
+		ex := &Collector{Log: log, Opts: opts, Manifest: &Manifest{Log: log}}
+		res, err := ex.ReleaseImageCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		// release image + 2 components for each release
+		if len(res) != 6 {
+			t.Fatalf("should return the images for both releases got %v", res)
+		}
+		if res[0].Destination != "docker://localhost:5000/test/openshift/release-images:4.12.3-x86_64" {
+			t.Fatalf("should tag the release image with its version got %s", res[0].Destination)
+		}
+		if res[1].Destination != "docker://localhost:5000/test/openshift/release@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea" || !res[1].PreserveDigests {
+			t.Fatalf("should push the components to openshift/release by digest got %v", res[1])
+		}
+		if res[3].Destination != "docker://localhost:5000/test/openshift/release-images:4.12.7-x86_64" {
+			t.Fatalf("should tag the release image with its version got %s", res[3].Destination)
+		}
+	})
+
+	t.Run("Testing ReleaseImageCollector diskToMirror list : should pass", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Platform.ReleaseDirs = []string{"dir://" + dir + "/test-lmz/release-images/ocp-release/4.12.7-x86_64"}
+		ex := &Collector{Log: log, Config: cfg, Opts: opts, Manifest: &Manifest{Log: log}}
+		res, err := ex.ReleaseImageCollector(context.Background())
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(res) != 3 {
+			t.Fatalf("should return the images for one release got %v", res)
+		}
+	})
+
+	t.Run("Testing ReleaseImageCollector diskToMirror no releases : should fail", func(t *testing.T) {
+		emptyOpts := opts
+		emptyOpts.Global = &mirror.GlobalOptions{Dir: t.TempDir()}
+		ex := &Collector{Log: log, Opts: emptyOpts, Manifest: &Manifest{Log: log}}
+		if _, err := ex.ReleaseImageCollector(context.Background()); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+
+	t.Run("Testing DiskReleases : should pass", func(t *testing.T) {
+		// a release-images dir deeper in the working dir (i.e an operator image) is not a release
+		nested := strings.Join([]string{dir, "test-lmz", "operator-images", "x", releaseImageDir, "ocp-release", "4.13.0-x86_64"}, "/")
+		if err := os.MkdirAll(nested, 0755); err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir + "/test-lmz/operator-images")
+		releases, err := DiskReleases(dir)
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(releases) != 2 || releases[0] != dir+"/test-lmz/release-images/ocp-release/4.12.3-x86_64" {
+			t.Fatalf("should only return the releases of the working dir got %v", releases)
+		}
+	})
+
+	t.Run("Testing ReleaseImageCollector diskToMirror from (no releases) : should pass", func(t *testing.T) {
+		fromOpts := opts
+		fromOpts.Global = &mirror.GlobalOptions{Dir: dir, ReleaseFrom: t.TempDir()}
+		ex := &Collector{Log: log, Opts: fromOpts, Manifest: &Manifest{Log: log}}
+		res, err := ex.ReleaseImageCollector(context.Background())
+		if err != nil || len(res) != 0 {
+			t.Fatalf("should not fail (only the release from dir is discovered) %v %v", res, err)
+		}
+	})
+}
+
+func TestReleaseImageTag(t *testing.T) {
+	log := clog.New("trace")
+	holdDir := t.TempDir()
+	if err := os.MkdirAll(holdDir+"/"+releaseManifests, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(holdDir+"/"+releaseImageExtractFullPath, []byte(`{"metadata":{"name":"4.12.3"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := v1alpha2.ImageSetConfiguration{}
+	cfg.Mirror.Platform.Architectures = []string{"arm64"}
+	ex := &Collector{Log: log, Config: cfg}
+
+	t.Run("Testing releaseImageTag : should pass", func(t *testing.T) {
+		if tag := ex.releaseImageTag("release-images/ocp-release/4.12.3-x86_64", holdDir); tag != "4.12.3-x86_64" {
+			t.Fatalf("should use the version directory got %s", tag)
+		}
+		if tag := ex.releaseImageTag("release-images/ocp-release/sha256-abc", holdDir); tag != "4.12.3-aarch64" {
+			t.Fatalf("should use the image-references version got %s", tag)
+		}
+		if tag := ex.releaseImageTag("release-images/ocp-release/sha256-abc", t.TempDir()); tag != "sha256-abc" {
+			t.Fatalf("should fall back to the directory got %s", tag)
+		}
+	})
+}
+
+func TestReleaseIndexDir(t *testing.T) {
+	t.Run("Testing releaseIndexDir : tag", func(t *testing.T) {
+		res := releaseIndexDir("quay.io/openshift-release-dev/ocp-release:4.12.0-x86_64")
+		if res != "ocp-release/4.12.0-x86_64" {
+			t.Fatalf("should return name/version got %s", res)
+		}
+	})
+	t.Run("Testing releaseIndexDir : digest", func(t *testing.T) {
+		res := releaseIndexDir("quay.io/openshift-release-dev/ocp-release@sha256:f30638f604520")
+		if res != "ocp-release/sha256-f30638f604520" {
+			t.Fatalf("should return name/sha256-digest got %s", res)
+		}
+	})
+}
+
+func TestResolveReleaseManifests(t *testing.T) {
+	log := clog.New("trace")
+	list := v1alpha3.OCIManifest{
+		MediaType: ociImageIndex,
+		Digest:    "sha256:3ef0b0141abd1548f60c4f3b23ecfc415142b0e842215f38e98610a3b2e52419",
+	}
+
+	t.Run("Testing resolveReleaseManifests single manifest : should pass", func(t *testing.T) {
+		ex := &Collector{Log: log, Manifest: &Manifest{Log: log, MultiArch: true}}
+		single := v1alpha3.OCIManifest{MediaType: "application/vnd.oci.image.manifest.v1+json", Digest: list.Digest}
+		res, err := ex.resolveReleaseManifests("test", single)
+		if err != nil || len(res) != 1 || res[0].Digest != single.Digest {
+			t.Fatalf("should return the manifest unchanged %v %v", res, err)
+		}
+	})
+
+	t.Run("Testing resolveReleaseManifests per arch : should pass", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Platform.Architectures = []string{"arm64"}
+		ex := &Collector{Log: log, Config: cfg, Manifest: &Manifest{Log: log, MultiArch: true}}
+		res, err := ex.resolveReleaseManifests("test", list)
+		if err != nil || len(res) != 1 || res[0].Platform.Architecture != "arm64" {
+			t.Fatalf("should return the arm64 manifest %v %v", res, err)
+		}
+	})
+
+	t.Run("Testing resolveReleaseManifests multi : should pass", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Platform.Architectures = []string{multiArch}
+		ex := &Collector{Log: log, Config: cfg, Manifest: &Manifest{Log: log, MultiArch: true}}
+		res, err := ex.resolveReleaseManifests("test", list)
+		if err != nil || len(res) != 2 {
+			t.Fatalf("should return all manifests %v %v", res, err)
+		}
+	})
+
+	t.Run("Testing resolveReleaseManifests unknown arch : should fail", func(t *testing.T) {
+		cfg := v1alpha2.ImageSetConfiguration{}
+		cfg.Mirror.Platform.Architectures = []string{"s390x"}
+		ex := &Collector{Log: log, Config: cfg, Manifest: &Manifest{Log: log, MultiArch: true}}
+		_, err := ex.resolveReleaseManifests("test", list)
+		if err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+}
+
+func TestUnionRelatedImages(t *testing.T) {
+	amd64 := []v1alpha3.RelatedImage{
+		{Name: "cli", Image: "quay.io/ocp/release@sha256:aaa"},
+		{Name: "installer", Image: "quay.io/ocp/release@sha256:bbb"},
+	}
+	arm64 := []v1alpha3.RelatedImage{
+		{Name: "cli", Image: "quay.io/ocp/release@sha256:aaa"},
+		{Name: "installer", Image: "quay.io/ocp/release@sha256:ccc"},
+		{Name: "baremetal", Image: "quay.io/ocp/release@sha256:ddd"},
+	}
+	t.Run("Testing unionRelatedImages : should pass", func(t *testing.T) {
+		res := unionRelatedImages(amd64, arm64, "arm64")
+		if len(res) != 4 {
+			t.Fatalf("should union the images got %v", res)
+		}
+		if res[2].Name != "installer-arm64" || res[3].Name != "baremetal" {
+			t.Fatalf("should suffix conflicting names got %v", res)
+		}
+	})
+}
+
+func TestComponentIncluded(t *testing.T) {
+	t.Run("Testing componentIncluded : should pass", func(t *testing.T) {
+		if !componentIncluded("cli", v1alpha2.ComponentFilter{}) {
+			t.Fatalf("should include all components by default")
+		}
+		exclude := v1alpha2.ComponentFilter{Exclude: []string{"*alibaba*", "azure-*"}}
+		if componentIncluded("alibaba-cloud-controller-manager", exclude) || componentIncluded("azure-disk-csi-driver", exclude) {
+			t.Fatalf("should exclude the matching components")
+		}
+		if !componentIncluded("baremetal-installer", exclude) {
+			t.Fatalf("should include the non matching components")
+		}
+		include := v1alpha2.ComponentFilter{Include: []string{"cli*", "installer"}, Exclude: []string{"cli-artifacts"}}
+		if !componentIncluded("cli", include) || !componentIncluded("installer", include) {
+			t.Fatalf("should include the matching components")
+		}
+		if componentIncluded("cli-artifacts", include) || componentIncluded("etcd", include) {
+			t.Fatalf("should exclude the components")
+		}
+	})
+
+	t.Run("Testing batcWorkerConverter with filter : should pass", func(t *testing.T) {
+		log := clog.New("trace")
+		dir := t.TempDir()
+		images := []v1alpha3.RelatedImage{
+			{Name: "cli", Image: "quay.io/ocp/release@sha256:aaa"},
+			{Name: "alibaba-disk-csi-driver", Image: "quay.io/ocp/release@sha256:bbb"},
+		}
+		res, err := batcWorkerConverter(log, dir, images, v1alpha2.ComponentFilter{Exclude: []string{"alibaba-*"}})
+		if err != nil || len(res) != 1 {
+			t.Fatalf("should filter the excluded component %v %v", res, err)
+		}
+	})
+}
+
 // setup mocks
 // we need to mock Manifest, Mirror, Cincinnati
 
@@ -225,6 +461,7 @@ type Manifest struct {
 	FailImageIndex    bool
 	FailImageManifest bool
 	FailExtract       bool
+	MultiArch         bool
 }
 
 type Cincinnati struct {
@@ -251,10 +488,10 @@ func (o *Manifest) GetRelatedImagesFromCatalogByFilter(filePath, label string, o
 
 func (o *Manifest) GetReleaseSchema(filePath string) ([]v1alpha3.RelatedImage, error) {
 	relatedImages := []v1alpha3.RelatedImage{
-		{Name: "testA", Image: "sometestimage-a@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
-		{Name: "testB", Image: "sometestimage-b@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
-		{Name: "testC", Image: "sometestimage-c@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
-		{Name: "testD", Image: "sometestimage-d@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
+		{Name: "testA", Image: "quay.io/openshift-release-dev/sometestimage-a@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
+		{Name: "testB", Image: "quay.io/openshift-release-dev/sometestimage-b@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
+		{Name: "testC", Image: "quay.io/openshift-release-dev/sometestimage-c@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
+		{Name: "testD", Image: "quay.io/openshift-release-dev/sometestimage-d@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea"},
 	}
 	return relatedImages, nil
 }
@@ -279,6 +516,26 @@ func (o *Manifest) GetImageManifest(name string) (*v1alpha3.OCISchema, error) {
 	if o.FailImageManifest {
 		return &v1alpha3.OCISchema{}, fmt.Errorf("forced error image index")
 	}
+	if o.MultiArch {
+		return &v1alpha3.OCISchema{
+			SchemaVersion: 2,
+			MediaType:     ociImageIndex,
+			Manifests: []v1alpha3.OCIManifest{
+				{
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Digest:    "sha256:1ef0b0141abd1548f60c4f3b23ecfc415142b0e842215f38e98610a3b2e52419",
+					Size:      567,
+					Platform:  &v1alpha3.OCIPlatform{Architecture: "amd64", OS: "linux"},
+				},
+				{
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Digest:    "sha256:2ef0b0141abd1548f60c4f3b23ecfc415142b0e842215f38e98610a3b2e52419",
+					Size:      567,
+					Platform:  &v1alpha3.OCIPlatform{Architecture: "arm64", OS: "linux"},
+				},
+			},
+		}, nil
+	}
 
 	return &v1alpha3.OCISchema{
 		SchemaVersion: 2,
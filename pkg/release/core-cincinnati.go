@@ -226,6 +226,80 @@ func CalculateUpgrades(ctx context.Context, c Client, arch, sourceChannel, targe
 	return current, requested, finalUpgrades, nil
 }
 
+// CalculateEUSUpgrades fetches and calculates the minimal set of update payloads for an EUS-to-EUS
+// upgrade (even minor to even minor) given the current and target version and the EUS channel prefix.
+// Each EUS hop is calculated in the target EUS channel so that the required intermediate odd-minor
+// release is included.
+func CalculateEUSUpgrades(ctx context.Context, c Client, arch, targetChannel string, startVer, reqVer semver.Version) (Update, Update, []Update, error) {
+	idx := strings.LastIndex(targetChannel, "-")
+	if idx == -1 {
+		return Update{}, Update{}, nil, fmt.Errorf("invalid channel name %s", targetChannel)
+	}
+	prefix := targetChannel[:idx]
+	if startVer.Major != reqVer.Major || startVer.Minor%2 != 0 || reqVer.Minor%2 != 0 || reqVer.Minor < startVer.Minor {
+		return Update{}, Update{}, nil, fmt.Errorf("eus upgrades require even minor versions within the same major version (%s -> %s)", startVer, reqVer)
+	}
+
+	if startVer.Minor == reqVer.Minor {
+		return GetUpdates(ctx, c, arch, fmt.Sprintf("%s-%v.%v", prefix, reqVer.Major, reqVer.Minor), startVer, reqVer)
+	}
+
+	var current, requested Update
+	var upgrades []Update
+	hopStart := startVer
+	for minor := startVer.Minor + 2; minor <= reqVer.Minor; minor += 2 {
+		currChannel := fmt.Sprintf("%s-%v.%v", prefix, reqVer.Major, minor)
+
+		var hopTarget semver.Version
+		var err error
+		if minor == reqVer.Minor {
+			hopTarget = reqVer
+		} else {
+			hopTarget, err = GetChannelMinOrMax(ctx, c, arch, currChannel, false)
+			if err != nil {
+				return Update{}, Update{}, nil, fmt.Errorf(ChannelInfo, currChannel, err)
+			}
+		}
+
+		isBlocked, err := handleBlockedEdges(ctx, c, arch, currChannel, hopStart)
+		if err != nil {
+			return Update{}, Update{}, nil, err
+		}
+		if isBlocked {
+			// If blocked path is found, just return the requested version and any accumulated
+			// upgrades to the caller
+			klog.Warningf("No eus upgrade path for %s in channel %s", hopStart.String(), currChannel)
+			_, requested, _, err = GetUpdates(ctx, c, arch, currChannel, hopTarget, hopTarget)
+			if err != nil {
+				return Update{}, Update{}, nil, err
+			}
+			return current, requested, upgrades, nil
+		}
+
+		hopCurrent, hopRequested, hopUpgrades, err := GetUpdates(ctx, c, arch, currChannel, hopStart, hopTarget)
+		if err != nil {
+			return Update{}, Update{}, nil, fmt.Errorf(ChannelInfo, currChannel, err)
+		}
+		if len(current.Image) == 0 {
+			current = hopCurrent
+		}
+		requested = hopRequested
+		upgrades = append(upgrades, hopUpgrades...)
+		hopStart = hopTarget
+	}
+
+	var finalUpgrades []Update
+	seen := make(map[string]struct{}, len(upgrades))
+	for _, upgrade := range upgrades {
+		if _, ok := seen[upgrade.Image]; !ok {
+			finalUpgrades = append(finalUpgrades, upgrade)
+			seen[upgrade.Image] = struct{}{}
+		}
+	}
+
+	return current, requested, finalUpgrades, nil
+}
+
 // calculate will calculate Cincinnati upgrades between channels by finding the latest versions in the source channels
 // and incrementing the minor version until the target channel is reached.
 func calculate(ctx context.Context, c Client, arch, sourceChannel, targetChannel string, startVer, reqVer semver.Version) (requested Update, upgrades []Update, err error) {
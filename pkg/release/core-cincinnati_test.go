@@ -410,6 +410,76 @@ func TestCalculateUpgrades(t *testing.T) {
 	}
 }
 
+func TestCalculateEUSUpgrades(t *testing.T) {
+	arch := "test-arch"
+
+	tests := []struct {
+		name            string
+		targetChannel   string
+		curr            semver.Version
+		req             semver.Version
+		currentUpdate   Update
+		requestedUpdate Update
+		neededUpdates   []Update
+		err             string
+	}{{
+		name:            "Success/EUSToEUS",
+		targetChannel:   "eus-4.2",
+		curr:            semver.MustParse("4.0.0-5"),
+		req:             semver.MustParse("4.2.0-5"),
+		currentUpdate:   Update{Version: semver.MustParse("4.0.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.0.0-5"},
+		requestedUpdate: Update{Version: semver.MustParse("4.2.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.2.0-5"},
+		neededUpdates: []Update{
+			{Version: semver.MustParse("4.0.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.0.0-5"},
+			{Version: semver.MustParse("4.1.0-6"), Image: "quay.io/openshift-release-dev/ocp-release:4.1.0-6"},
+			{Version: semver.MustParse("4.2.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.2.0-5"},
+		},
+	}, {
+		name:            "SuccessWithWarning/BlockedEdge",
+		targetChannel:   "eus-4.2",
+		curr:            semver.MustParse("4.0.0-4"),
+		req:             semver.MustParse("4.2.0-5"),
+		requestedUpdate: Update{Version: semver.MustParse("4.2.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.2.0-5"},
+	}, {
+		name:          "Failure/OddMinor",
+		targetChannel: "eus-4.2",
+		curr:          semver.MustParse("4.1.0-6"),
+		req:           semver.MustParse("4.2.0-5"),
+		err:           "eus upgrades require even minor versions within the same major version (4.1.0-6 -> 4.2.0-5)",
+	}, {
+		name:          "Failure/InvalidChannel",
+		targetChannel: "eus",
+		curr:          semver.MustParse("4.0.0-5"),
+		req:           semver.MustParse("4.2.0-5"),
+		err:           "invalid channel name eus",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requestQuery := make(chan string, 10)
+			defer close(requestQuery)
+
+			handler := getHandlerMulti(t, requestQuery)
+
+			ts := httptest.NewServer(http.HandlerFunc(handler))
+			t.Cleanup(ts.Close)
+
+			endpoint, err := url.Parse(ts.URL)
+			require.NoError(t, err)
+
+			cur, req, updates, err := CalculateEUSUpgrades(context.Background(), &mockClient{url: endpoint}, arch, test.targetChannel, test.curr, test.req)
+
+			if test.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, test.currentUpdate, cur)
+				require.Equal(t, test.requestedUpdate, req)
+				require.Equal(t, test.neededUpdates, updates)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
 func TestHandleBlockedEdges(t *testing.T) {
 	arch := "test-arch"
 
@@ -814,6 +884,37 @@ func getHandlerMulti(t *testing.T, requestQuery chan<- string) http.HandlerFunc
 				t.Fatal(err)
 				return
 			}
+		case ch == "eus-4.2":
+			_, err := w.Write([]byte(`{
+				"nodes": [
+				{
+					"version": "4.0.0-5",
+					"payload": "quay.io/openshift-release-dev/ocp-release:4.0.0-5"
+				},
+				{
+					"version": "4.0.0-6",
+					"payload": "quay.io/openshift-release-dev/ocp-release:4.0.0-6"
+				},
+				{
+					"version": "4.1.0-6",
+					"payload": "quay.io/openshift-release-dev/ocp-release:4.1.0-6"
+				},
+				{
+					"version": "4.2.0-3",
+					"payload": "quay.io/openshift-release-dev/ocp-release:4.2.0-3"
+				},
+				{
+					"version": "4.2.0-5",
+					"payload": "quay.io/openshift-release-dev/ocp-release:4.2.0-5"
+				}
+				],
+				"edges": [[0,1],[0,2],[1,2],[2,3],[2,4],[3,4]]
+			}`))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Fatal(err)
+				return
+			}
 		default:
 			t.Fail()
 		}
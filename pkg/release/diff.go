@@ -0,0 +1,209 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+)
+
+const (
+	ComponentAdded   string = "added"
+	ComponentRemoved string = "removed"
+	ComponentChanged string = "changed"
+)
+
+// ReleaseDiff - the component changes between two releases
+type ReleaseDiff struct {
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Changes   []ComponentChange `json:"changes"`
+	Unchanged int               `json:"unchanged"`
+}
+
+// ComponentChange - a component (image-references tag) that was added, removed or changed
+type ComponentChange struct {
+	Name       string `json:"name"`
+	Change     string `json:"change"`
+	FromImage  string `json:"fromImage,omitempty"`
+	ToImage    string `json:"toImage,omitempty"`
+	FromCommit string `json:"fromCommit,omitempty"`
+	ToCommit   string `json:"toCommit,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+// DiffReleases - compares the components (image-references, see GetReleaseSchema) of two releases
+func DiffReleases(fromName string, from []v1alpha3.RelatedImage, toName string, to []v1alpha3.RelatedImage) ReleaseDiff {
+	diff := ReleaseDiff{From: fromName, To: toName}
+	fromImages := make(map[string]v1alpha3.RelatedImage)
+	for _, img := range from {
+		fromImages[img.Name] = img
+	}
+	toImages := make(map[string]v1alpha3.RelatedImage)
+	for _, img := range to {
+		toImages[img.Name] = img
+	}
+
+	for name, t := range toImages {
+		f, ok := fromImages[name]
+		switch {
+		case !ok:
+			diff.Changes = append(diff.Changes, ComponentChange{
+				Name:     name,
+				Change:   ComponentAdded,
+				ToImage:  t.Image,
+				ToCommit: commitID(t),
+				Source:   sourceLocation(t),
+			})
+		case f.Image != t.Image:
+			diff.Changes = append(diff.Changes, ComponentChange{
+				Name:       name,
+				Change:     ComponentChanged,
+				FromImage:  f.Image,
+				ToImage:    t.Image,
+				FromCommit: commitID(f),
+				ToCommit:   commitID(t),
+				Source:     sourceLocation(t),
+			})
+		default:
+			diff.Unchanged++
+		}
+	}
+	for name, f := range fromImages {
+		if _, ok := toImages[name]; !ok {
+			diff.Changes = append(diff.Changes, ComponentChange{
+				Name:       name,
+				Change:     ComponentRemoved,
+				FromImage:  f.Image,
+				FromCommit: commitID(f),
+				Source:     sourceLocation(f),
+			})
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		if diff.Changes[i].Change != diff.Changes[j].Change {
+			return diff.Changes[i].Change < diff.Changes[j].Change
+		}
+		return diff.Changes[i].Name < diff.Changes[j].Name
+	})
+	return diff
+}
+
+// WriteDiff - renders the diff in the requested format (text or json)
+func WriteDiff(w io.Writer, diff ReleaseDiff, format string) error {
+	switch format {
+	case PlanFormatText:
+		counts := make(map[string]int)
+		for _, c := range diff.Changes {
+			counts[c.Change]++
+		}
+		fmt.Fprintf(w, "release diff %s -> %s\n", diff.From, diff.To)
+		fmt.Fprintf(w, "changed: %d added: %d removed: %d unchanged: %d\n",
+			counts[ComponentChanged], counts[ComponentAdded], counts[ComponentRemoved], diff.Unchanged)
+		for _, c := range diff.Changes {
+			switch c.Change {
+			case ComponentChanged:
+				fmt.Fprintf(w, "  ~ %s %s -> %s%s\n", c.Name, shortDigest(c.FromImage), shortDigest(c.ToImage), commitRange(c.FromCommit, c.ToCommit))
+			case ComponentAdded:
+				fmt.Fprintf(w, "  + %s %s%s\n", c.Name, shortDigest(c.ToImage), commitRange("", c.ToCommit))
+			case ComponentRemoved:
+				fmt.Fprintf(w, "  - %s %s%s\n", c.Name, shortDigest(c.FromImage), commitRange(c.FromCommit, ""))
+			}
+		}
+	case PlanFormatJson:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+	default:
+		return fmt.Errorf("unknown diff format %q. Choose one of the supported options: 'text' or 'json'", format)
+	}
+	return nil
+}
+
+// ReleaseComponents - reads the components (full image-references) of an extracted release
+func ReleaseComponents(m manifest.ManifestInterface, releaseDir string) ([]v1alpha3.RelatedImage, error) {
+	return m.GetReleaseSchema(releaseDir + "/" + releaseImageExtractFullPath)
+}
+
+// FindReleaseDir - looks up the extracted release (hold-release/<name>/<version>) in dir
+// release is either a version (4.12.3 matches 4.12.3-x86_64) or a name/version
+func FindReleaseDir(dir, release string) (string, error) {
+	var found []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || d.Name() != releaseImageExtractDir {
+			return nil
+		}
+		matches, err := filepath.Glob(path + "/*/*")
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			version := filepath.Base(m)
+			name := filepath.Base(filepath.Dir(m))
+			if release == version || strings.HasPrefix(version, release+"-") || release == name+"/"+version {
+				found = append(found, m)
+			}
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return "", err
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("release %s not found in %s", release, dir)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("release %s is ambiguous %v, use name/version", release, found)
+	}
+}
+
+// commitID - the build commit id of a component (if known)
+func commitID(img v1alpha3.RelatedImage) string {
+	if img.Annotations == nil {
+		return ""
+	}
+	return img.Annotations.IoOpenshiftBuildCommitID
+}
+
+// sourceLocation - the build source location of a component (if known)
+func sourceLocation(img v1alpha3.RelatedImage) string {
+	if img.Annotations == nil {
+		return ""
+	}
+	return img.Annotations.IoOpenshiftBuildSourceLocation
+}
+
+// shortDigest - returns the digest of an image reference
+func shortDigest(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	return image
+}
+
+// commitRange - formats the build commit ids (if known)
+func commitRange(from, to string) string {
+	switch {
+	case len(from) == 0 && len(to) == 0:
+		return ""
+	case from == to || len(from) == 0:
+		return " (commit " + to + ")"
+	case len(to) == 0:
+		return " (commit " + from + ")"
+	default:
+		return " (commit " + from + " -> " + to + ")"
+	}
+}
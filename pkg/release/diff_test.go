@@ -0,0 +1,83 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+// writeReleaseSchema - writes the image-references of an extracted release
+func writeReleaseSchema(t *testing.T, releaseDir, version string, tags map[string][2]string) {
+	schema := v1alpha3.ReleaseSchema{Metadata: v1alpha3.Metadata{Name: version}}
+	for name, v := range tags {
+		tag := v1alpha3.Tags{Name: name, From: v1alpha3.From{Kind: "DockerImage", Name: v[0]}}
+		tag.Annotations.IoOpenshiftBuildCommitID = v[1]
+		schema.Spec.Tags = append(schema.Spec.Tags, tag)
+	}
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(releaseDir+"/"+releaseManifests, 0755))
+	require.NoError(t, os.WriteFile(releaseDir+"/"+releaseImageExtractFullPath, data, 0644))
+}
+
+func TestDiffReleases(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	writeReleaseSchema(t, dir+"/4.12.3", "4.12.3", map[string][2]string{
+		"cli":       {"quay.io/ocp/art-dev@sha256:aaa", "c1"},
+		"installer": {"quay.io/ocp/art-dev@sha256:bbb", "c2"},
+		"old":       {"quay.io/ocp/art-dev@sha256:ccc", "c3"},
+	})
+	writeReleaseSchema(t, dir+"/4.12.7", "4.12.7", map[string][2]string{
+		"cli":       {"quay.io/ocp/art-dev@sha256:aaa", "c1"},
+		"installer": {"quay.io/ocp/art-dev@sha256:ddd", "c4"},
+		"new":       {"quay.io/ocp/art-dev@sha256:eee", "c5"},
+	})
+
+	from, err := ReleaseComponents(manifest.New(log), dir+"/4.12.3")
+	require.NoError(t, err)
+	to, err := ReleaseComponents(manifest.New(log), dir+"/4.12.7")
+	require.NoError(t, err)
+
+	diff := DiffReleases("4.12.3", from, "4.12.7", to)
+	require.Equal(t, "4.12.3", diff.From)
+	require.Equal(t, 1, diff.Unchanged)
+	require.Equal(t, []ComponentChange{
+		{Name: "new", Change: ComponentAdded, ToImage: "quay.io/ocp/art-dev@sha256:eee", ToCommit: "c5"},
+		{Name: "installer", Change: ComponentChanged, FromImage: "quay.io/ocp/art-dev@sha256:bbb", ToImage: "quay.io/ocp/art-dev@sha256:ddd", FromCommit: "c2", ToCommit: "c4"},
+		{Name: "old", Change: ComponentRemoved, FromImage: "quay.io/ocp/art-dev@sha256:ccc", FromCommit: "c3"},
+	}, diff.Changes)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDiff(&buf, diff, PlanFormatText))
+	require.Contains(t, buf.String(), "changed: 1 added: 1 removed: 1 unchanged: 1")
+	require.Contains(t, buf.String(), "  ~ installer sha256:bbb -> sha256:ddd (commit c2 -> c4)")
+	require.Error(t, WriteDiff(&buf, diff, "yaml"))
+}
+
+func TestFindReleaseDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, r := range []string{"ocp-release/4.12.3-x86_64", "ocp-release/4.12.7-x86_64", "ocp-release/4.12.7-aarch64"} {
+		require.NoError(t, os.MkdirAll(strings.Join([]string{dir, "test-lmz", releaseImageExtractDir, r}, "/"), 0755))
+	}
+
+	found, err := FindReleaseDir(dir, "4.12.3")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(found, "hold-release/ocp-release/4.12.3-x86_64"))
+
+	found, err = FindReleaseDir(dir, "ocp-release/4.12.7-aarch64")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(found, "hold-release/ocp-release/4.12.7-aarch64"))
+
+	_, err = FindReleaseDir(dir, "4.12.7")
+	require.Error(t, err)
+	_, err = FindReleaseDir(dir, "4.13.0")
+	require.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	PlanFormatText string = "text"
+	PlanFormatJson string = "json"
+	PlanFormatDot  string = "dot"
+)
+
+// UpgradePlan - the result of an upgrade path calculation
+type UpgradePlan struct {
+	Arch     string        `json:"arch"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Channels []string      `json:"channels"`
+	Releases []PlanRelease `json:"releases"`
+	Hops     []PlanHop     `json:"hops"`
+}
+
+// PlanRelease - a release (node) on the upgrade path
+type PlanRelease struct {
+	Version string `json:"version"`
+	Channel string `json:"channel"`
+	Image   string `json:"image"`
+}
+
+// PlanHop - a single upgrade (edge) on the upgrade path
+type PlanHop struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PlanUpgrade calculates the shortest upgrade path between two versions
+// the channels are derived from the prefix and the major.minor of each version
+func PlanUpgrade(ctx context.Context, c Client, arch, prefix string, from, to semver.Version) (UpgradePlan, error) {
+	if to.LT(from) {
+		return UpgradePlan{}, fmt.Errorf("target version %s is lower than the source version %s", to, from)
+	}
+
+	sourceChannel := channelForVersion(prefix, from)
+	targetChannel := channelForVersion(prefix, to)
+	current, requested, updates, err := CalculateUpgrades(ctx, c, arch, sourceChannel, targetChannel, from, to)
+	if err != nil {
+		return UpgradePlan{}, err
+	}
+	return newUpgradePlan(arch, prefix, from, to, current, requested, updates), nil
+}
+
+// newUpgradePlan - builds an ordered (deduplicated) plan from the
+// cincinnati calculation results
+func newUpgradePlan(arch, prefix string, from, to semver.Version, current, requested Update, updates []Update) UpgradePlan {
+	plan := UpgradePlan{Arch: arch, From: from.String(), To: to.String()}
+
+	all := append([]Update{current}, updates...)
+	all = append(all, requested)
+	seen := make(map[string]bool)
+	var nodes []Update
+	for _, u := range all {
+		if len(u.Image) == 0 || seen[u.Version.String()] {
+			continue
+		}
+		seen[u.Version.String()] = true
+		nodes = append(nodes, u)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Version.LT(nodes[j].Version)
+	})
+
+	channels := make(map[string]bool)
+	for i, u := range nodes {
+		ch := channelForVersion(prefix, u.Version)
+		if !channels[ch] {
+			channels[ch] = true
+			plan.Channels = append(plan.Channels, ch)
+		}
+		plan.Releases = append(plan.Releases, PlanRelease{Version: u.Version.String(), Channel: ch, Image: u.Image})
+		if i > 0 {
+			plan.Hops = append(plan.Hops, PlanHop{From: nodes[i-1].Version.String(), To: u.Version.String()})
+		}
+	}
+	return plan
+}
+
+// WritePlan - renders the plan in the requested format (text, json or dot)
+func WritePlan(w io.Writer, plan UpgradePlan, format string) error {
+	switch format {
+	case PlanFormatText:
+		fmt.Fprintf(w, "upgrade path %s -> %s (%s)\n", plan.From, plan.To, plan.Arch)
+		fmt.Fprintf(w, "channels: %s\n", strings.Join(plan.Channels, ", "))
+		fmt.Fprintf(w, "hops: %d\n", len(plan.Hops))
+		for i, hop := range plan.Hops {
+			fmt.Fprintf(w, "  %d. %s -> %s\n", i+1, hop.From, hop.To)
+		}
+		fmt.Fprintf(w, "release images:\n")
+		for _, r := range plan.Releases {
+			fmt.Fprintf(w, "  %s [%s] %s\n", r.Version, r.Channel, r.Image)
+		}
+	case PlanFormatJson:
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+	case PlanFormatDot:
+		fmt.Fprintf(w, "digraph upgrade {\n")
+		fmt.Fprintf(w, "  rankdir=LR;\n")
+		for _, r := range plan.Releases {
+			fmt.Fprintf(w, "  %q [label=%q];\n", r.Version, r.Version+"\n"+r.Channel)
+		}
+		for _, hop := range plan.Hops {
+			fmt.Fprintf(w, "  %q -> %q;\n", hop.From, hop.To)
+		}
+		fmt.Fprintf(w, "}\n")
+	default:
+		return fmt.Errorf("unknown plan format %q. Choose one of the supported options: 'text', 'json' or 'dot'", format)
+	}
+	return nil
+}
+
+// PlanToImageSetConfig - creates an ImageSetConfiguration platform stanza
+// that mirrors exactly the releases on the upgrade path (one exact release per hop)
+func PlanToImageSetConfig(plan UpgradePlan) v1alpha2.ImageSetConfiguration {
+	var releases []v1alpha2.Release
+	for _, r := range plan.Releases {
+		releases = append(releases, v1alpha2.Release{Version: r.Version, Channel: r.Channel})
+	}
+	cfg := v1alpha2.ImageSetConfiguration{}
+	cfg.SetGroupVersionKind(v1alpha2.GroupVersion.WithKind(v1alpha2.ImageSetConfigurationKind))
+	cfg.Mirror.Platform = v1alpha2.Platform{
+		Architectures: []string{plan.Arch},
+		Releases:      releases,
+	}
+	return cfg
+}
+
+// WritePlanImageSetConfig - writes the plan as an ImageSetConfiguration (yaml)
+func WritePlanImageSetConfig(w io.Writer, plan UpgradePlan) error {
+	data, err := yaml.Marshal(PlanToImageSetConfig(plan))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append([]byte("---\n"), data...))
+	return err
+}
+
+// channelForVersion - returns the channel name (prefix-major.minor) for a version
+func channelForVersion(prefix string, version semver.Version) string {
+	return fmt.Sprintf("%s-%d.%d", prefix, version.Major, version.Minor)
+}
@@ -0,0 +1,93 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanUpgrade(t *testing.T) {
+	arch := "test-arch"
+
+	requestQuery := make(chan string, 10)
+	defer close(requestQuery)
+
+	handler := getHandlerMulti(t, requestQuery)
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(ts.Close)
+
+	endpoint, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	t.Run("Testing PlanUpgrade : should pass", func(t *testing.T) {
+		plan, err := PlanUpgrade(context.Background(), &mockClient{url: endpoint}, arch, "stable", semver.MustParse("4.0.0-5"), semver.MustParse("4.2.0-3"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"stable-4.0", "stable-4.1", "stable-4.2"}, plan.Channels)
+		require.Equal(t, []PlanHop{
+			{From: "4.0.0-5", To: "4.0.0-6"},
+			{From: "4.0.0-6", To: "4.0.0-8"},
+			{From: "4.0.0-8", To: "4.1.0-6"},
+			{From: "4.1.0-6", To: "4.2.0-3"},
+		}, plan.Hops)
+		require.Len(t, plan.Releases, 5)
+		require.Equal(t, "quay.io/openshift-release-dev/ocp-release:4.2.0-3", plan.Releases[4].Image)
+	})
+
+	t.Run("Testing PlanUpgrade : should fail (lower target)", func(t *testing.T) {
+		_, err := PlanUpgrade(context.Background(), &mockClient{url: endpoint}, arch, "stable", semver.MustParse("4.2.0-3"), semver.MustParse("4.0.0-5"))
+		require.Error(t, err)
+	})
+}
+
+func TestWritePlan(t *testing.T) {
+	plan := newUpgradePlan("amd64", "stable", semver.MustParse("4.0.0-5"), semver.MustParse("4.1.0-6"),
+		Update{Version: semver.MustParse("4.0.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.0.0-5"},
+		Update{Version: semver.MustParse("4.1.0-6"), Image: "quay.io/openshift-release-dev/ocp-release:4.1.0-6"},
+		[]Update{
+			{Version: semver.MustParse("4.0.0-5"), Image: "quay.io/openshift-release-dev/ocp-release:4.0.0-5"},
+			{Version: semver.MustParse("4.0.0-8"), Image: "quay.io/openshift-release-dev/ocp-release:4.0.0-8"},
+		},
+	)
+
+	for _, format := range []string{PlanFormatText, PlanFormatJson, PlanFormatDot} {
+		t.Run("Testing WritePlan : should pass "+format, func(t *testing.T) {
+			var b bytes.Buffer
+			err := WritePlan(&b, plan, format)
+			require.NoError(t, err)
+			require.True(t, strings.Contains(b.String(), "4.0.0-8"))
+		})
+	}
+
+	t.Run("Testing WritePlan : should fail", func(t *testing.T) {
+		var b bytes.Buffer
+		err := WritePlan(&b, plan, "xml")
+		require.Error(t, err)
+	})
+
+	t.Run("Testing PlanToImageSetConfig : should pass", func(t *testing.T) {
+		cfg := PlanToImageSetConfig(plan)
+		require.Equal(t, []string{"amd64"}, cfg.Mirror.Platform.Architectures)
+		require.Empty(t, cfg.Mirror.Platform.Channels)
+		require.Equal(t, []v1alpha2.Release{
+			{Version: "4.0.0-5", Channel: "stable-4.0"},
+			{Version: "4.0.0-8", Channel: "stable-4.0"},
+			{Version: "4.1.0-6", Channel: "stable-4.1"},
+		}, cfg.Mirror.Platform.Releases)
+		require.NoError(t, config.Validate(&cfg))
+
+		var b bytes.Buffer
+		err := WritePlanImageSetConfig(&b, plan)
+		require.NoError(t, err)
+		require.True(t, strings.Contains(b.String(), "kind: ImageSetConfiguration"))
+		require.True(t, strings.Contains(b.String(), "releases:"))
+	})
+}
@@ -0,0 +1,264 @@
+package release
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha3"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+)
+
+const (
+	toolsDir      string = "tools"
+	checksumsFile string = "sha256sum.txt"
+	linuxPlatform string = "linux"
+)
+
+// ToolBinary - a binary shipped in a release component image
+type ToolBinary struct {
+	Component string
+	Path      string
+	Platform  string
+	Name      string
+}
+
+// linuxTools - the binaries extracted for every release
+var linuxTools = []ToolBinary{
+	{Component: "cli", Path: "usr/bin/oc", Platform: linuxPlatform, Name: "oc"},
+	{Component: "installer", Path: "usr/bin/openshift-install", Platform: linuxPlatform, Name: "openshift-install"},
+}
+
+// otherOSTools - the cross platform clients shipped in the cli-artifacts image
+// these are packaged as tarballs (openshift-client-<platform>-<version>.tar.gz)
+var otherOSTools = []ToolBinary{
+	{Component: "cli-artifacts", Path: "usr/share/openshift/linux_arm64/oc", Platform: "linux-arm64", Name: "oc"},
+	{Component: "cli-artifacts", Path: "usr/share/openshift/mac/oc", Platform: "mac", Name: "oc"},
+	{Component: "cli-artifacts", Path: "usr/share/openshift/mac_arm64/oc", Platform: "mac-arm64", Name: "oc"},
+	{Component: "cli-artifacts", Path: "usr/share/openshift/windows/oc.exe", Platform: "windows", Name: "oc.exe"},
+}
+
+// ExtractTools - extracts the oc and openshift-install binaries from a mirrored release
+// release is the name/version directory (i.e. ocp-release/4.12.0-x86_64) in the working dir
+// the binaries and a sha256sum.txt file are written to working-dir/tools/<version>/
+func ExtractTools(log clog.PluggableLoggerInterface, m manifest.ManifestInterface, dir, release string, allOS bool) ([]string, error) {
+	releaseDir := strings.Join([]string{dir, releaseImageDir, release}, "/")
+	holdDir := strings.Join([]string{dir, releaseImageExtractDir, release}, "/")
+	version := filepath.Base(release)
+	outDir := strings.Join([]string{dir, toolsDir, version}, "/")
+
+	allRelatedImages, err := m.GetReleaseSchema(strings.Join([]string{holdDir, releaseImageExtractFullPath}, "/"))
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	tools := linuxTools
+	if allOS {
+		tools = append(append([]ToolBinary{}, linuxTools...), otherOSTools...)
+	}
+
+	// group the binaries by component so that each image is only read once
+	components := make(map[string]map[string]ToolBinary)
+	for _, tool := range tools {
+		if len(findRelatedImage(tool.Component, allRelatedImages)) == 0 {
+			return nil, fmt.Errorf(errMsg, "component "+tool.Component+" not found in release "+release)
+		}
+		if components[tool.Component] == nil {
+			components[tool.Component] = make(map[string]ToolBinary)
+		}
+		components[tool.Component][tool.Path] = tool
+	}
+
+	err = os.MkdirAll(outDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	var files []string
+	for component, wanted := range components {
+		componentDir := strings.Join([]string{releaseDir, "images", component}, "/")
+		mfst, err := componentManifest(m, componentDir, toolsArch(version))
+		if err != nil {
+			return nil, fmt.Errorf(errMsg, "component "+component+" : "+err.Error())
+		}
+		found, err := extractToolFiles(componentDir, outDir, mfst.Layers, wanted)
+		if err != nil {
+			return nil, fmt.Errorf(errMsg, err)
+		}
+		for path, tool := range wanted {
+			if !found[path] {
+				return nil, fmt.Errorf(errMsg, "binary "+path+" not found in component "+component)
+			}
+			name := tool.Name
+			if tool.Platform != linuxPlatform {
+				name, err = packageTool(outDir, version, tool)
+				if err != nil {
+					return nil, fmt.Errorf(errMsg, err)
+				}
+			}
+			log.Info("extracted %s (%s) to %s ", tool.Name, tool.Platform, outDir+"/"+name)
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+
+	err = writeChecksums(outDir, files)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	return files, nil
+}
+
+// componentManifest - reads the image manifest of a component, mirrored as a dir: image
+// (manifest.json and version), for a manifest list (multi payload) the manifest of the
+// linux instance for arch is read (<hash>.manifest.json)
+func componentManifest(m manifest.ManifestInterface, componentDir, arch string) (*v1alpha3.OCISchema, error) {
+	for _, file := range []string{dirManifest, dirVersion} {
+		if _, err := os.Stat(componentDir + "/" + file); errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("has not been mirrored to %s (no %s)", componentDir, file)
+		}
+	}
+	mfst, err := m.GetImageManifest(componentDir + "/" + dirManifest)
+	if err != nil {
+		return nil, err
+	}
+	if mfst.MediaType != ociImageIndex && mfst.MediaType != dockerManifestList && len(mfst.Manifests) == 0 {
+		return mfst, nil
+	}
+	for _, lm := range mfst.Manifests {
+		if lm.Platform != nil && lm.Platform.OS == linuxPlatform && lm.Platform.Architecture == arch && strings.Contains(lm.Digest, "sha256") {
+			return m.GetImageManifest(componentDir + "/" + strings.Split(lm.Digest, ":")[1] + "." + dirManifest)
+		}
+	}
+	return nil, fmt.Errorf("no %s/%s manifest in the manifest list of %s", linuxPlatform, arch, componentDir)
+}
+
+// toolsArch - the architecture of the binaries extracted from a multi payload, from the
+// release version suffix (i.e 4.12.0-aarch64), amd64 for the multi payload
+func toolsArch(version string) string {
+	switch version[strings.LastIndex(version, "-")+1:] {
+	case "aarch64", "arm64":
+		return "arm64"
+	case "ppc64le":
+		return "ppc64le"
+	case "s390x":
+		return "s390x"
+	default:
+		return "amd64"
+	}
+}
+
+// extractToolFiles - walks the (gzipped) layers in order and writes the wanted files
+// linux binaries are written as <name>, other platforms as <platform>/<name>
+// later layers overwrite earlier ones
+func extractToolFiles(fromPath, toPath string, layers []v1alpha3.OCIManifest, wanted map[string]ToolBinary) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for _, layer := range layers {
+		if !strings.Contains(layer.Digest, "sha256") {
+			return nil, fmt.Errorf("the digest format is not correct %s ", layer.Digest)
+		}
+		f, err := os.Open(fromPath + "/" + strings.Split(layer.Digest, ":")[1])
+		if err != nil {
+			return nil, err
+		}
+		err = extractLayerFiles(f, toPath, wanted, found)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// extractLayerFiles - extracts the wanted files from a single layer
+func extractLayerFiles(gzipStream io.Reader, toPath string, wanted map[string]ToolBinary, found map[string]bool) error {
+	uncompressedStream, err := gzip.NewReader(gzipStream)
+	if err != nil {
+		return fmt.Errorf("extract: gzipStream - %w", err)
+	}
+	tarReader := tar.NewReader(uncompressedStream)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("extract: Next() failed: %s", err.Error())
+		}
+		tool, ok := wanted[strings.TrimPrefix(strings.TrimPrefix(header.Name, "./"), "/")]
+		if !ok || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		file := toPath + "/" + tool.Name
+		if tool.Platform != linuxPlatform {
+			file = strings.Join([]string{toPath, tool.Platform, tool.Name}, "/")
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return fmt.Errorf("extract: Mkdir() failed: %v", err)
+			}
+		}
+		outFile, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+		if err != nil {
+			return fmt.Errorf("extract: Create() failed: %v", err)
+		}
+		if _, err := io.Copy(outFile, tarReader); err != nil {
+			outFile.Close()
+			return fmt.Errorf("extract: Copy() failed: %v", err)
+		}
+		outFile.Close()
+		found[tool.Path] = true
+	}
+	return nil
+}
+
+// packageTool - packages an extracted (non linux) binary as a tarball
+// and removes the extracted platform directory
+func packageTool(outDir, version string, tool ToolBinary) (string, error) {
+	platformDir := outDir + "/" + tool.Platform
+	name := fmt.Sprintf("openshift-client-%s-%s.tar.gz", tool.Platform, version)
+	data, err := os.ReadFile(platformDir + "/" + tool.Name)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Create(outDir + "/" + name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	err = tw.WriteHeader(&tar.Header{Name: tool.Name, Mode: 0755, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	if err != nil {
+		return "", err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gw.Close(); err != nil {
+		return "", err
+	}
+	return name, os.RemoveAll(platformDir)
+}
+
+// writeChecksums - writes a sha256sum compatible file for the extracted tools
+func writeChecksums(outDir string, files []string) error {
+	var sb strings.Builder
+	for _, name := range files {
+		data, err := os.ReadFile(outDir + "/" + name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "%x  %s\n", sha256.Sum256(data), name)
+	}
+	return os.WriteFile(outDir+"/"+checksumsFile, []byte(sb.String()), 0644)
+}
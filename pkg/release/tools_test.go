@@ -0,0 +1,190 @@
+package release
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+)
+
+func TestExtractTools(t *testing.T) {
+	log := clog.New("trace")
+	release := "ocp-release/4.12.0-x86_64"
+
+	t.Run("Testing ExtractTools : should pass", func(t *testing.T) {
+		dir := t.TempDir()
+		setupToolsRelease(t, dir, release, true)
+
+		files, err := ExtractTools(log, manifest.New(log), dir, release, true)
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(files) != 6 {
+			t.Fatalf("should extract 6 files got %v", files)
+		}
+		outDir := dir + "/tools/4.12.0-x86_64"
+		data, err := os.ReadFile(outDir + "/oc")
+		if err != nil || string(data) != "oc-v2" {
+			t.Fatalf("should extract oc from the last layer got %s %v", data, err)
+		}
+		if _, err := os.Stat(outDir + "/openshift-client-windows-4.12.0-x86_64.tar.gz"); err != nil {
+			t.Fatalf("should package the windows client %v", err)
+		}
+		sums, err := os.ReadFile(outDir + "/" + checksumsFile)
+		if err != nil {
+			t.Fatalf("should write checksums %v", err)
+		}
+		if !strings.Contains(string(sums), fmt.Sprintf("%x  oc\n", sha256.Sum256([]byte("oc-v2")))) {
+			t.Fatalf("should contain the oc checksum got %s", sums)
+		}
+	})
+
+	t.Run("Testing ExtractTools (multi payload) : should pass", func(t *testing.T) {
+		dir := t.TempDir()
+		multi := "ocp-release/4.12.0-aarch64"
+		setupMultiToolsRelease(t, dir, multi)
+
+		files, err := ExtractTools(log, manifest.New(log), dir, multi, false)
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(files) != 2 {
+			t.Fatalf("should extract 2 files got %v", files)
+		}
+		data, err := os.ReadFile(dir + "/tools/4.12.0-aarch64/oc")
+		if err != nil || string(data) != "oc-arm64" {
+			t.Fatalf("should extract oc from the arm64 manifest got %s %v", data, err)
+		}
+	})
+
+	t.Run("Testing ExtractTools (not a dir image) : should fail", func(t *testing.T) {
+		dir := t.TempDir()
+		setupToolsRelease(t, dir, release, true)
+		if err := os.Remove(strings.Join([]string{dir, releaseImageDir, release, "images", "cli", dirVersion}, "/")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ExtractTools(log, manifest.New(log), dir, release, true); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+
+	t.Run("Testing ExtractTools missing component : should fail", func(t *testing.T) {
+		dir := t.TempDir()
+		setupToolsRelease(t, dir, release, false)
+
+		if _, err := ExtractTools(log, manifest.New(log), dir, release, true); err == nil {
+			t.Fatalf("should fail")
+		}
+	})
+}
+
+// setupToolsRelease - creates a release on disk with the cli, installer
+// (and optionally cli-artifacts) components as dir: images (see batcWorkerConverter)
+func setupToolsRelease(t *testing.T, dir, release string, artifacts bool) {
+	writeImageReferences(t, dir, release)
+	components := map[string][]map[string]string{
+		"cli":       {{"usr/bin/oc": "oc-v1", "usr/bin/other": "x"}, {"./usr/bin/oc": "oc-v2"}},
+		"installer": {{"usr/bin/openshift-install": "install"}},
+	}
+	if artifacts {
+		components["cli-artifacts"] = []map[string]string{{
+			"usr/share/openshift/linux_arm64/oc": "oc-arm64",
+			"usr/share/openshift/mac/oc":         "oc-mac",
+			"usr/share/openshift/mac_arm64/oc":   "oc-mac-arm64",
+			"usr/share/openshift/windows/oc.exe": "oc-windows",
+		}}
+	}
+	for component, layers := range components {
+		componentDir := strings.Join([]string{dir, releaseImageDir, release, "images", component}, "/")
+		mfst := writeDirImageBlobs(t, componentDir, layers)
+		if err := os.WriteFile(componentDir+"/"+dirManifest, mfst, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// setupMultiToolsRelease - creates a multi payload release on disk, the components are
+// dir: images with a manifest list (manifest.json) and a manifest per architecture
+func setupMultiToolsRelease(t *testing.T, dir, release string) {
+	writeImageReferences(t, dir, release)
+	for _, component := range []string{"cli", "installer"} {
+		componentDir := strings.Join([]string{dir, releaseImageDir, release, "images", component}, "/")
+		var instances []string
+		for _, arch := range []string{"amd64", "arm64"} {
+			mfst := writeDirImageBlobs(t, componentDir, []map[string]string{{
+				"usr/bin/oc":                "oc-" + arch,
+				"usr/bin/openshift-install": "install-" + arch,
+			}})
+			d := fmt.Sprintf("%x", sha256.Sum256(mfst))
+			if err := os.WriteFile(componentDir+"/"+d+"."+dirManifest, mfst, 0644); err != nil {
+				t.Fatal(err)
+			}
+			instances = append(instances, fmt.Sprintf(`{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","digest":"sha256:%s","size":%d,"platform":{"architecture":"%s","os":"linux"}}`, d, len(mfst), arch))
+		}
+		list := `{"schemaVersion":2,"mediaType":"` + dockerManifestList + `","manifests":[` + strings.Join(instances, ",") + `]}`
+		if err := os.WriteFile(componentDir+"/"+dirManifest, []byte(list), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeImageReferences - writes the image-references of the extracted release
+func writeImageReferences(t *testing.T, dir, release string) {
+	holdDir := strings.Join([]string{dir, releaseImageExtractDir, release, releaseManifests}, "/")
+	if err := os.MkdirAll(holdDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	refs := `{"kind":"ImageStream","spec":{"tags":[
+		{"name":"cli","from":{"name":"quay.io/ocp/art-dev@sha256:aaa"}},
+		{"name":"cli-artifacts","from":{"name":"quay.io/ocp/art-dev@sha256:bbb"}},
+		{"name":"installer","from":{"name":"quay.io/ocp/art-dev@sha256:ccc"}}]}}`
+	if err := os.WriteFile(holdDir+"/"+imageReferences, []byte(refs), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeDirImageBlobs - writes the (gzipped tar) layers, the config and the version file
+// of a dir: image (blobs named by their hash), returns the image manifest
+func writeDirImageBlobs(t *testing.T, componentDir string, layers []map[string]string) []byte {
+	if err := os.MkdirAll(componentDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(componentDir+"/"+dirVersion, []byte("Directory Transport Version: 1.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var digests []string
+	for _, files := range layers {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		for name, content := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tw.Close()
+		gw.Close()
+		digest := fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+		if err := os.WriteFile(componentDir+"/"+digest, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		digests = append(digests, fmt.Sprintf(`{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"sha256:%s","size":%d}`, digest, buf.Len()))
+	}
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	configDigest := fmt.Sprintf("%x", sha256.Sum256(config))
+	if err := os.WriteFile(componentDir+"/"+configDigest, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"sha256:%s","size":%d},"layers":[%s]}`,
+		configDigest, len(config), strings.Join(digests, ",")))
+}
@@ -0,0 +1,44 @@
+package release
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/blobstore"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/manifest"
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/verify"
+)
+
+// MissingComponents - returns the release components (image-references of the extracted releases in dir)
+// that are not in the release images cache (not a dir: image) or the blob store, the components excluded by the filter are ignored
+func MissingComponents(m manifest.ManifestInterface, dir string, filter v1alpha2.ComponentFilter) ([]string, error) {
+	releases, err := filepath.Glob(strings.Join([]string{dir, releaseImageExtractDir, "*", "*"}, "/"))
+	if err != nil {
+		return nil, err
+	}
+	stored, err := blobstore.Refs(dir, releaseImageDir)
+	if err != nil {
+		return nil, err
+	}
+	o := &Collector{Manifest: m}
+	var missing []string
+	for _, cacheDir := range releases {
+		images, err := o.releaseRelatedImages(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(filepath.Join(dir, releaseImageExtractDir), cacheDir)
+		for _, img := range images {
+			if !componentIncluded(img.Name, filter) {
+				continue
+			}
+			ref := strings.Join([]string{releaseImageDir, filepath.ToSlash(rel), "images", img.Name}, "/")
+			component := dir + "/" + ref
+			if !verify.IsImage(component) && !stored[ref] {
+				missing = append(missing, component)
+			}
+		}
+	}
+	return missing, nil
+}
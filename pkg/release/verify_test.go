@@ -0,0 +1,47 @@
+package release
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lmzuccarelli/golang-fb-mirror/pkg/api/v1alpha2"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+)
+
+func TestMissingComponents(t *testing.T) {
+	log := clog.New("trace")
+	dir := t.TempDir()
+	for _, d := range []string{
+		dir + "/" + releaseImageExtractDir + "/ocp-release/4.14.1/release-manifests",
+		dir + "/" + releaseImageDir + "/ocp-release/4.14.1/images/testA",
+		dir + "/" + releaseImageDir + "/ocp-release/4.14.1/images/testB",
+	} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// testA is a dir: image, testB an empty dir (an interrupted copy)
+	for _, file := range []string{dirManifest, dirVersion} {
+		if err := os.WriteFile(dir+"/"+releaseImageDir+"/ocp-release/4.14.1/images/testA/"+file, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Testing MissingComponents : should pass", func(t *testing.T) {
+		missing, err := MissingComponents(&Manifest{Log: log}, dir, v1alpha2.ComponentFilter{})
+		if err != nil {
+			t.Fatalf("should not fail %v", err)
+		}
+		if len(missing) != 3 || !strings.HasSuffix(missing[0], "/images/testB") || !strings.HasSuffix(missing[2], "/images/testD") {
+			t.Fatalf("should report testB, testC and testD %v", missing)
+		}
+	})
+
+	t.Run("Testing MissingComponents with filter : should pass", func(t *testing.T) {
+		missing, err := MissingComponents(&Manifest{Log: log}, dir, v1alpha2.ComponentFilter{Exclude: []string{"testB", "testC", "testD"}})
+		if err != nil || len(missing) != 0 {
+			t.Fatalf("should ignore the excluded components %v %v", missing, err)
+		}
+	})
+}
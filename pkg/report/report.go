@@ -0,0 +1,126 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ReportFile    string = "run-report.json"
+	StatusSuccess string = "success"
+	StatusFailed  string = "failed"
+	StatusSkipped string = "skipped"
+	errMsg        string = "[Report] %v "
+)
+
+// Report - the outcome of a run
+type Report struct {
+	Mode       string             `json:"mode"`
+	ConfigHash string             `json:"configHash,omitempty"`
+	Started    time.Time          `json:"started"`
+	Finished   time.Time          `json:"finished"`
+	Status     string             `json:"status"`
+	Error      string             `json:"error,omitempty"`
+	Totals     map[string]*Totals `json:"totals"`
+	Images     []Image            `json:"images"`
+	mu         sync.Mutex
+}
+
+// Image - the outcome of an image copy
+type Image struct {
+	Type        string  `json:"type,omitempty"`
+	Source      string  `json:"source"`
+	Destination string  `json:"destination"`
+	Digest      string  `json:"digest,omitempty"`
+	Size        int64   `json:"size"`
+	Duration    float64 `json:"durationSeconds"`
+	Retries     int     `json:"retries"`
+	Status      string  `json:"status"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Totals - the image counts and size of an image type
+type Totals struct {
+	Images    int   `json:"images"`
+	Succeeded int   `json:"succeeded"`
+	Failed    int   `json:"failed"`
+	Skipped   int   `json:"skipped"`
+	Size      int64 `json:"size"`
+}
+
+// New - returns a report for a run, the config hash is the sha256 of the config file (if any)
+func New(mode, configPath string) *Report {
+	r := &Report{Mode: mode, Started: time.Now().UTC(), Totals: make(map[string]*Totals), Images: []Image{}}
+	if data, err := os.ReadFile(configPath); err == nil {
+		sum := sha256.Sum256(data)
+		r.ConfigHash = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return r
+}
+
+// Add - adds the outcome of image copies (safe for concurrent use)
+func (o *Report) Add(images ...Image) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Images = append(o.Images, images...)
+}
+
+// Finish - sets the status of the run and the totals per image type ("all" for all the images)
+func (o *Report) Finish(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Finished = time.Now().UTC()
+	o.Status = StatusSuccess
+	if err != nil {
+		o.Status = StatusFailed
+		o.Error = err.Error()
+	}
+	sort.SliceStable(o.Images, func(i, j int) bool {
+		return o.Images[i].Type < o.Images[j].Type
+	})
+	o.Totals = map[string]*Totals{"all": {}}
+	for _, img := range o.Images {
+		if len(img.Type) > 0 && o.Totals[img.Type] == nil {
+			o.Totals[img.Type] = &Totals{}
+		}
+		for _, t := range []*Totals{o.Totals["all"], o.Totals[img.Type]} {
+			if t == nil {
+				continue
+			}
+			t.Images++
+			t.Size += img.Size
+			switch img.Status {
+			case StatusSuccess:
+				t.Succeeded++
+			case StatusFailed:
+				t.Failed++
+			case StatusSkipped:
+				t.Skipped++
+			}
+		}
+	}
+}
+
+// Write - writes the report (json) to the file
+func (o *Report) Write(file string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
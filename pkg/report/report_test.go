@@ -0,0 +1,53 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "isc.yaml")
+	require.NoError(t, os.WriteFile(cfg, []byte("kind: ImageSetConfiguration"), 0644))
+
+	t.Run("Testing Report : should pass", func(t *testing.T) {
+		r := New("mirrorToDisk", cfg)
+		require.Equal(t, "sha256:", r.ConfigHash[:7])
+		r.Add(
+			Image{Type: "release", Source: "docker://registry/a:v1", Size: 10, Status: StatusSuccess},
+			Image{Type: "operator", Source: "docker://registry/b:v1", Size: 20, Retries: 2, Status: StatusSuccess},
+		)
+		r.Add(
+			Image{Type: "release", Source: "docker://registry/c:v1", Status: StatusFailed, Error: "forced error"},
+			Image{Type: "operator", Source: "docker://registry/d:v1", Status: StatusSkipped},
+		)
+		r.Finish(fmt.Errorf("forced error"))
+		require.Equal(t, StatusFailed, r.Status)
+		require.Equal(t, &Totals{Images: 4, Succeeded: 2, Failed: 1, Skipped: 1, Size: 30}, r.Totals["all"])
+		require.Equal(t, &Totals{Images: 2, Succeeded: 1, Failed: 1, Size: 10}, r.Totals["release"])
+		require.Equal(t, &Totals{Images: 2, Succeeded: 1, Skipped: 1, Size: 20}, r.Totals["operator"])
+		require.Equal(t, "operator", r.Images[0].Type)
+
+		file := filepath.Join(dir, "logs", ReportFile)
+		require.NoError(t, r.Write(file))
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		res := &Report{}
+		require.NoError(t, json.Unmarshal(data, res))
+		require.Equal(t, r.Totals, res.Totals)
+		require.Len(t, res.Images, 4)
+	})
+
+	t.Run("Testing Report (no config) : should pass", func(t *testing.T) {
+		r := New("diskToMirror", "")
+		r.Finish(nil)
+		require.Empty(t, r.ConfigHash)
+		require.Equal(t, StatusSuccess, r.Status)
+		require.Equal(t, &Totals{}, r.Totals["all"])
+	})
+}
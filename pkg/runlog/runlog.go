@@ -0,0 +1,154 @@
+package runlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+)
+
+const (
+	Root            string = "logs"
+	timestampFormat string = "20060102-150405"
+	errMsg          string = "[RunLog] %v "
+)
+
+// runDir - the run dirs (<timestamp>-<mode>), other content of the logs dir is kept
+var runDir = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}-`)
+
+// fileName - the characters not allowed in a log file name
+var fileName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Create - creates the logs dir of a run (root/<timestamp>-<mode>) and removes the
+// oldest run dirs so that at most keep runs are kept (0 keeps all the runs)
+func Create(root, mode string, keep int) (string, error) {
+	dir := filepath.Join(root, time.Now().Format(timestampFormat)+"-"+mode)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf(errMsg, err)
+	}
+	if keep > 0 {
+		if err := Prune(root, keep); err != nil {
+			return dir, err
+		}
+	}
+	return dir, nil
+}
+
+// Prune - removes the oldest run dirs in root, the last keep runs are kept
+func Prune(root string, keep int) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	var runs []string
+	for _, e := range entries {
+		if e.IsDir() && runDir.MatchString(e.Name()) {
+			runs = append(runs, e.Name())
+		}
+	}
+	// the timestamp prefix sorts by time
+	sort.Strings(runs)
+	for i := 0; i < len(runs)-keep; i++ {
+		if err := os.RemoveAll(filepath.Join(root, runs[i])); err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+	return nil
+}
+
+// FileName - the log file name of an image, the reference without the transport
+// i.e docker://quay.io/org/app:v1 -> quay.io_org_app_v1.log
+func FileName(image string) string {
+	if i := strings.Index(image, "://"); i >= 0 {
+		image = image[i+3:]
+	} else if i := strings.Index(image, ":"); i >= 0 && !strings.Contains(image[:i], "/") {
+		// oci:path and dir:path
+		image = image[i+1:]
+	}
+	return strings.Trim(fileName.ReplaceAllString(image, "_"), "_.") + ".log"
+}
+
+// File - a log file, the lines written are echoed to the console as they are written
+type File struct {
+	log   clog.PluggableLoggerInterface
+	file  *os.File
+	name  string
+	quiet bool
+	buf   []byte
+	mu    sync.Mutex
+}
+
+// Open - creates the log file name in dir (the logs dir of the run, the root logs dir if empty)
+// the lines are echoed (debug level, prefixed with the name) unless quiet, the file is still
+// usable (echo only) when it can't be created
+func Open(log clog.PluggableLoggerInterface, dir, name string, quiet bool) (*File, error) {
+	if len(dir) == 0 {
+		dir = Root
+	}
+	lf := &File{log: log, name: strings.TrimSuffix(name, ".log"), quiet: quiet}
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return lf, fmt.Errorf(errMsg, err)
+	}
+	lf.file = f
+	return lf, nil
+}
+
+// Writer - a writer of the mirror output (a bufio.Writer), with a one byte buffer
+// the writes go straight to the file so the console echo is live
+func (o *File) Writer() *bufio.Writer {
+	return bufio.NewWriterSize(o, 1)
+}
+
+// Write - writes to the file and echoes the complete lines
+func (o *File) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file != nil {
+		if _, err := o.file.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	if o.quiet {
+		return len(p), nil
+	}
+	o.buf = append(o.buf, p...)
+	for {
+		i := bytes.IndexByte(o.buf, '\n')
+		if i < 0 {
+			break
+		}
+		o.echo(string(o.buf[:i]))
+		o.buf = o.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close - echoes the last (incomplete) line and closes the file
+func (o *File) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.quiet {
+		o.echo(string(o.buf))
+	}
+	o.buf = nil
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Close()
+}
+
+func (o *File) echo(line string) {
+	line = strings.TrimSpace(line)
+	if len(line) > 0 {
+		o.log.Debug("[%s] %s ", o.name, strings.ToLower(line))
+	}
+}
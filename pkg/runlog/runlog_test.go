@@ -0,0 +1,77 @@
+package runlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLog(t *testing.T) {
+	log := clog.New("trace")
+
+	t.Run("Testing Create : should pass", func(t *testing.T) {
+		root := t.TempDir()
+		for i := 1; i <= 4; i++ {
+			require.NoError(t, os.MkdirAll(filepath.Join(root, fmt.Sprintf("2024010%d-120000-mirrorToDisk", i)), 0755))
+		}
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "other"), 0755))
+		dir, err := Create(root, "diskToMirror", 3)
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(dir, "-diskToMirror"))
+
+		entries, err := os.ReadDir(root)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		require.Equal(t, []string{"20240103-120000-mirrorToDisk", "20240104-120000-mirrorToDisk", filepath.Base(dir), "other"}, names)
+	})
+
+	t.Run("Testing Create (keep all) : should pass", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "20240101-120000-mirrorToDisk"), 0755))
+		_, err := Create(root, "mirrorToDisk", 0)
+		require.NoError(t, err)
+		entries, err := os.ReadDir(root)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+	})
+
+	t.Run("Testing FileName : should pass", func(t *testing.T) {
+		require.Equal(t, "quay.io_org_app_v1.log", FileName("docker://quay.io/org/app:v1"))
+		require.Equal(t, "localhost_5000_app_sha256_abc.log", FileName("docker://localhost:5000/app@sha256:abc"))
+		require.Equal(t, "working-dir_additional-images_app_v1.log", FileName("oci:working-dir/additional-images/app/v1"))
+		require.Equal(t, "working-dir_release-images_etcd.log", FileName("dir://working-dir/release-images/etcd"))
+	})
+
+	t.Run("Testing Open : should pass", func(t *testing.T) {
+		dir := t.TempDir()
+		lf, err := Open(log, dir, FileName("docker://quay.io/org/app:v1"), false)
+		require.NoError(t, err)
+		w := lf.Writer()
+		_, err = w.WriteString("Copying blob sha256:abc\nCopying config")
+		require.NoError(t, err)
+		require.NoError(t, w.Flush())
+		// the lines are in the file before it is closed
+		data, err := os.ReadFile(filepath.Join(dir, "quay.io_org_app_v1.log"))
+		require.NoError(t, err)
+		require.Equal(t, "Copying blob sha256:abc\nCopying config", string(data))
+		require.Equal(t, "Copying config", string(lf.buf))
+		require.NoError(t, lf.Close())
+		require.Empty(t, lf.buf)
+	})
+
+	t.Run("Testing Open (no dir) : should fail", func(t *testing.T) {
+		lf, err := Open(log, filepath.Join(t.TempDir(), "missing"), "app.log", true)
+		require.Error(t, err)
+		_, err = lf.Write([]byte("echo only\n"))
+		require.NoError(t, err)
+		require.NoError(t, lf.Close())
+	})
+}
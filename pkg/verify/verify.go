@@ -0,0 +1,251 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	indexFile   string = "index.json"
+	layoutFile  string = "oci-layout"
+	blobsDir    string = "blobs"
+	dirManifest string = "manifest.json"
+	dirVersion  string = "version"
+)
+
+// skipDirs - the working dir content that is not an image cache (extracted releases and catalogs)
+// the blob stores are oci layouts and are verified as such
+var skipDirs = map[string]bool{"hold-release": true, "hold-operator": true, "logs": true}
+
+// Problem - missing or corrupted content
+type Problem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Report - the result of a verification
+type Report struct {
+	Images   int       `json:"images"`
+	Blobs    int       `json:"blobs"`
+	Problems []Problem `json:"problems"`
+}
+
+// Add - adds a problem to the report
+func (o *Report) Add(path, format string, val ...interface{}) {
+	o.Problems = append(o.Problems, Problem{Path: path, Message: fmt.Sprintf(format, val...)})
+}
+
+// Images - verifies every oci layout and dir: image in dir
+// each blob is hashed and compared to its digest, the manifests (index.json and manifest.json)
+// are read and the manifests, configs and layers they reference must exist with the same size
+func Images(log clog.PluggableLoggerInterface, dir string) (*Report, error) {
+	report := &Report{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if skipDirs[d.Name()] || (d.Name() == blobsDir && exists(filepath.Join(filepath.Dir(path), layoutFile))) {
+			return filepath.SkipDir
+		}
+		switch {
+		case isLayout(path):
+			log.Debug("verifying oci layout %s ", path)
+			report.Images++
+			verifyLayout(path, report)
+		case isDirImage(path):
+			log.Debug("verifying dir image %s ", path)
+			report.Images++
+			verifyDirImage(path, report)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[Verify] %v ", err)
+	}
+	return report, nil
+}
+
+// IsImage - true if dir is an image, an oci layout (oci-layout and index.json)
+// or a dir: image (manifest.json and version)
+func IsImage(dir string) bool {
+	return isLayout(dir) || isDirImage(dir)
+}
+
+func isLayout(dir string) bool {
+	return exists(filepath.Join(dir, layoutFile)) && exists(filepath.Join(dir, indexFile))
+}
+
+func isDirImage(dir string) bool {
+	return exists(filepath.Join(dir, dirManifest)) && exists(filepath.Join(dir, dirVersion))
+}
+
+// verifyLayout - checks the blobs and the images referenced by the index of an oci layout
+func verifyLayout(dir string, report *Report) {
+	algorithms, err := os.ReadDir(filepath.Join(dir, blobsDir))
+	if err != nil {
+		report.Add(dir, "blobs %v", err)
+		return
+	}
+	for _, algorithm := range algorithms {
+		files, err := os.ReadDir(filepath.Join(dir, blobsDir, algorithm.Name()))
+		if err != nil {
+			report.Add(dir, "blobs %v", err)
+			continue
+		}
+		for _, f := range files {
+			d := digest.NewDigestFromEncoded(digest.Algorithm(algorithm.Name()), f.Name())
+			verifyBlob(filepath.Join(dir, blobsDir, algorithm.Name(), f.Name()), d, report)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		report.Add(dir, "%v", err)
+		return
+	}
+	var index imgspecv1.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		report.Add(filepath.Join(dir, indexFile), "%v", err)
+		return
+	}
+	if len(index.Manifests) == 0 {
+		report.Add(filepath.Join(dir, indexFile), "no manifests")
+	}
+	blobPath := func(d digest.Digest) string {
+		return filepath.Join(dir, blobsDir, d.Algorithm().String(), d.Encoded())
+	}
+	for _, desc := range index.Manifests {
+		verifyManifest(dir, types.BlobInfo{Digest: desc.Digest, Size: desc.Size}, blobPath, report)
+	}
+}
+
+// verifyDirImage - checks the blobs and the manifest of a dir: image
+func verifyDirImage(dir string, report *Report) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		report.Add(dir, "%v", err)
+		return
+	}
+	for _, f := range files {
+		d := digest.NewDigestFromEncoded(digest.Canonical, f.Name())
+		if f.IsDir() || d.Validate() != nil {
+			continue
+		}
+		verifyBlob(filepath.Join(dir, f.Name()), d, report)
+	}
+	mb, err := os.ReadFile(filepath.Join(dir, dirManifest))
+	if err != nil {
+		report.Add(dir, "%v", err)
+		return
+	}
+	blobPath := func(d digest.Digest) string {
+		if d == digest.FromBytes(mb) {
+			return filepath.Join(dir, dirManifest)
+		}
+		return filepath.Join(dir, d.Encoded())
+	}
+	verifyManifest(dir, types.BlobInfo{Digest: digest.FromBytes(mb), Size: int64(len(mb))}, blobPath, report)
+}
+
+// verifyManifest - checks that a manifest (or manifest list) and the blobs it references exist
+func verifyManifest(dir string, info types.BlobInfo, blobPath func(digest.Digest) string, report *Report) {
+	if !verifyExists(dir, info, blobPath, "manifest", report) {
+		return
+	}
+	mb, err := os.ReadFile(blobPath(info.Digest))
+	if err != nil {
+		report.Add(dir, "manifest %s %v", info.Digest, err)
+		return
+	}
+	mt := manifest.GuessMIMEType(mb)
+	if manifest.MIMETypeIsMultiImage(mt) {
+		list, err := manifest.ListFromBlob(mb, mt)
+		if err != nil {
+			report.Add(dir, "manifest list %s %v", info.Digest, err)
+			return
+		}
+		for _, instance := range list.Instances() {
+			ii, err := list.Instance(instance)
+			if err != nil {
+				report.Add(dir, "manifest list %s %v", info.Digest, err)
+				continue
+			}
+			verifyManifest(dir, types.BlobInfo{Digest: instance, Size: ii.Size}, blobPath, report)
+		}
+		return
+	}
+	m, err := manifest.FromBlob(mb, mt)
+	if err != nil {
+		report.Add(dir, "manifest %s %v", info.Digest, err)
+		return
+	}
+	// schema1 manifests have no config
+	if config := m.ConfigInfo(); len(config.Digest) > 0 {
+		verifyExists(dir, config, blobPath, "config", report)
+	}
+	for _, layer := range m.LayerInfos() {
+		// foreign layers are not copied
+		if len(layer.URLs) > 0 && !exists(blobPath(layer.Digest)) {
+			continue
+		}
+		verifyExists(dir, layer.BlobInfo, blobPath, "layer", report)
+	}
+}
+
+// verifyExists - checks that a referenced blob exists with the expected size
+func verifyExists(dir string, info types.BlobInfo, blobPath func(digest.Digest) string, kind string, report *Report) bool {
+	if err := info.Digest.Validate(); err != nil {
+		report.Add(dir, "%s %s %v", kind, info.Digest, err)
+		return false
+	}
+	fi, err := os.Stat(blobPath(info.Digest))
+	if err != nil {
+		report.Add(dir, "missing %s %s", kind, info.Digest)
+		return false
+	}
+	if info.Size >= 0 && fi.Size() != info.Size {
+		report.Add(dir, "%s %s size %d expected %d", kind, info.Digest, fi.Size(), info.Size)
+	}
+	return true
+}
+
+// verifyBlob - hashes a blob and compares it to its digest
+func verifyBlob(path string, d digest.Digest, report *Report) {
+	report.Blobs++
+	if err := d.Validate(); err != nil {
+		report.Add(path, "unexpected blob %v", err)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		report.Add(path, "%v", err)
+		return
+	}
+	defer f.Close()
+	verifier := d.Verifier()
+	if _, err := io.Copy(verifier, f); err != nil {
+		report.Add(path, "%v", err)
+		return
+	}
+	if !verifier.Verified() {
+		report.Add(path, "corrupted blob (digest mismatch) %s", d)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
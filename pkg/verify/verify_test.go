@@ -0,0 +1,139 @@
+package verify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clog "github.com/lmzuccarelli/golang-fb-mirror/pkg/log"
+	"github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImages(t *testing.T) {
+	log := clog.New("trace")
+
+	t.Run("Testing Images : should pass", func(t *testing.T) {
+		root := t.TempDir()
+		writeLayout(t, filepath.Join(root, "additional-images/team/a/v1"), []byte("layer a"))
+		writeDirImage(t, filepath.Join(root, "release-images/4.14.1/images/etcd"), []byte("layer b"))
+		// not image caches
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "hold-release/4.14.1/release-manifests"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "hold-release/4.14.1/release-manifests/image-references"), []byte("{}"), 0644))
+
+		report, err := Images(log, root)
+		require.NoError(t, err)
+		require.Empty(t, report.Problems)
+		require.Equal(t, 2, report.Images)
+		// manifest, config and layer of the layout, config and layer of the dir image
+		require.Equal(t, 5, report.Blobs)
+	})
+
+	t.Run("Testing IsImage : should pass", func(t *testing.T) {
+		root := t.TempDir()
+		writeLayout(t, filepath.Join(root, "layout"), []byte("layer a"))
+		writeDirImage(t, filepath.Join(root, "dir"), []byte("layer b"))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "empty"), 0755))
+		require.True(t, IsImage(filepath.Join(root, "layout")))
+		require.True(t, IsImage(filepath.Join(root, "dir")))
+		require.False(t, IsImage(filepath.Join(root, "empty")))
+		require.False(t, IsImage(filepath.Join(root, "missing")))
+	})
+
+	t.Run("Testing Images (corrupted blob) : should fail", func(t *testing.T) {
+		root := t.TempDir()
+		layout := filepath.Join(root, "a")
+		writeLayout(t, layout, []byte("layer a"))
+		layer := filepath.Join(layout, blobsDir, "sha256", digest.FromString("layer a").Encoded())
+		require.NoError(t, os.WriteFile(layer, []byte("layer x"), 0644))
+
+		report, err := Images(log, root)
+		require.NoError(t, err)
+		require.Len(t, report.Problems, 1)
+		require.Equal(t, layer, report.Problems[0].Path)
+		require.Contains(t, report.Problems[0].Message, "corrupted blob")
+	})
+
+	t.Run("Testing Images (missing layer) : should fail", func(t *testing.T) {
+		root := t.TempDir()
+		dir := filepath.Join(root, "etcd")
+		writeDirImage(t, dir, []byte("layer a"), []byte("layer b"))
+		require.NoError(t, os.Remove(filepath.Join(dir, digest.FromString("layer b").Encoded())))
+
+		report, err := Images(log, root)
+		require.NoError(t, err)
+		require.Len(t, report.Problems, 1)
+		require.Equal(t, dir, report.Problems[0].Path)
+		require.Contains(t, report.Problems[0].Message, "missing layer")
+	})
+
+	t.Run("Testing Images (size mismatch) : should fail", func(t *testing.T) {
+		root := t.TempDir()
+		layout := filepath.Join(root, "a")
+		mb := writeLayout(t, layout, []byte("layer a"))
+		index, err := json.Marshal(imgspecv1.Index{
+			Versioned: imgspecs.Versioned{SchemaVersion: 2},
+			Manifests: []imgspecv1.Descriptor{{MediaType: imgspecv1.MediaTypeImageManifest, Digest: mb, Size: 1}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(layout, indexFile), index, 0644))
+
+		report, err := Images(log, root)
+		require.NoError(t, err)
+		require.Len(t, report.Problems, 1)
+		require.Contains(t, report.Problems[0].Message, "size")
+	})
+
+	t.Run("Testing Images (no dir) : should fail", func(t *testing.T) {
+		_, err := Images(log, "/does/not/exist")
+		require.Error(t, err)
+	})
+}
+
+// writeLayout - writes a single image oci layout, returns the manifest digest
+func writeLayout(t *testing.T, dir string, layers ...[]byte) digest.Digest {
+	blobs := filepath.Join(dir, blobsDir, "sha256")
+	require.NoError(t, os.MkdirAll(blobs, 0755))
+	write := func(data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(blobs, digest.FromBytes(data).Encoded()), data, 0644))
+	}
+	mb := imageManifest(t, write, layers...)
+	write(mb)
+	desc := imgspecv1.Descriptor{MediaType: imgspecv1.MediaTypeImageManifest, Digest: digest.FromBytes(mb), Size: int64(len(mb))}
+	index, err := json.Marshal(imgspecv1.Index{Versioned: imgspecs.Versioned{SchemaVersion: 2}, Manifests: []imgspecv1.Descriptor{desc}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, indexFile), index, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, layoutFile), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0644))
+	return desc.Digest
+}
+
+// writeDirImage - writes a dir: image
+func writeDirImage(t *testing.T, dir string, layers ...[]byte) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	mb := imageManifest(t, func(data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, digest.FromBytes(data).Encoded()), data, 0644))
+	}, layers...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, dirManifest), mb, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, dirVersion), []byte("Directory Transport Version: 1.1\n"), 0644))
+}
+
+// imageManifest - writes the config and layers with the blob writer, returns the manifest
+func imageManifest(t *testing.T, blob func([]byte), layers ...[]byte) []byte {
+	config := []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]}}`)
+	blob(config)
+	m := imgspecv1.Manifest{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config:    imgspecv1.Descriptor{MediaType: imgspecv1.MediaTypeImageConfig, Digest: digest.FromBytes(config), Size: int64(len(config))},
+	}
+	for _, layer := range layers {
+		blob(layer)
+		m.Layers = append(m.Layers, imgspecv1.Descriptor{MediaType: imgspecv1.MediaTypeImageLayerGzip, Digest: digest.FromBytes(layer), Size: int64(len(layer))})
+	}
+	mb, err := json.Marshal(m)
+	require.NoError(t, err)
+	return mb
+}